@@ -1,6 +1,7 @@
 package account
 
 import (
+	"context"
 	"crypto/rand"
 	"database/sql"
 	"encoding/json"
@@ -212,3 +213,12 @@ func AuthenticateUser(db *sql.DB, email, password string) (*User, error) {
 	}
 	return user, nil
 }
+
+// DeleteExpiredSessions removes sessions past their expiry and returns how many were removed.
+func DeleteExpiredSessions(ctx context.Context, db *sql.DB) (int64, error) {
+	res, err := db.ExecContext(ctx, `DELETE FROM sessions WHERE expires_at < now()`)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,160 @@
+package admin
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/quehorrifico/mana-tomb/backend/db"
+	"github.com/quehorrifico/mana-tomb/backend/models"
+	"github.com/quehorrifico/mana-tomb/backend/scryfall"
+	"github.com/quehorrifico/mana-tomb/backend/utils"
+)
+
+var DB *sql.DB
+
+// Source is where runs triggered through the admin API download bulk data from.
+var Source scryfall.CardSource
+
+// Default and maximum number of runs returned by ListIngestionRuns.
+const (
+	defaultRunsLimit = 50
+	maxRunsLimit     = 500
+)
+
+const runColumns = `id, bulk_type, COALESCE(bulk_data_id::text, ''), source, trigger, status, started_at, finished_at,
+	bytes, cards_read, cards_added, cards_changed, cards_retired, cards_rejected, COALESCE(error, '')`
+
+// List recent ingestion runs, newest first, e.g. /api/ingestion-runs?status=failed&bulk_type=oracle_cards&limit=20
+func ListIngestionRuns(w http.ResponseWriter, r *http.Request) {
+	limit := defaultRunsLimit
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxRunsLimit {
+			http.Error(w, "Invalid 'limit', expected 1-500", http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	rows, err := DB.Query(`
+		SELECT `+runColumns+`
+		FROM ingestion_runs
+		WHERE ($1 = '' OR status = $1) AND ($2 = '' OR bulk_type = $2)
+		ORDER BY started_at DESC, id DESC
+		LIMIT $3;
+	`, r.URL.Query().Get("status"), r.URL.Query().Get("bulk_type"), limit)
+	if err != nil {
+		http.Error(w, "Error fetching ingestion runs", http.StatusInternalServerError)
+		log.Println("❌ Error fetching ingestion runs:", err)
+		return
+	}
+	defer rows.Close()
+
+	runs := []models.IngestionRun{}
+	for rows.Next() {
+		run, err := scanRun(rows)
+		if err != nil {
+			http.Error(w, "Error fetching ingestion runs", http.StatusInternalServerError)
+			log.Println("❌ Error scanning ingestion run:", err)
+			return
+		}
+		runs = append(runs, run)
+	}
+	if err := rows.Err(); err != nil {
+		http.Error(w, "Error fetching ingestion runs", http.StatusInternalServerError)
+		log.Println("❌ Error reading ingestion runs:", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(runs)
+}
+
+// Get one ingestion run with the cards it rejected, e.g. /api/ingestion-runs/42
+func GetIngestionRun(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Run ID must be a number", http.StatusBadRequest)
+		return
+	}
+
+	run, err := scanRun(DB.QueryRow(`SELECT `+runColumns+` FROM ingestion_runs WHERE id = $1`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "Ingestion run not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Error fetching ingestion run", http.StatusInternalServerError)
+		log.Println("❌ Error fetching ingestion run:", err)
+		return
+	}
+
+	rows, err := DB.Query(`
+		SELECT COALESCE(card_id, ''), COALESCE(card_name, ''), reason
+		FROM ingestion_run_errors
+		WHERE run_id = $1
+		ORDER BY id;
+	`, id)
+	if err != nil {
+		http.Error(w, "Error fetching ingestion run", http.StatusInternalServerError)
+		log.Println("❌ Error fetching ingestion run errors:", err)
+		return
+	}
+	defer rows.Close()
+
+	run.Errors = []models.IngestionRunError{}
+	for rows.Next() {
+		var runErr models.IngestionRunError
+		if err := rows.Scan(&runErr.CardID, &runErr.CardName, &runErr.Reason); err != nil {
+			http.Error(w, "Error fetching ingestion run", http.StatusInternalServerError)
+			log.Println("❌ Error scanning ingestion run error:", err)
+			return
+		}
+		run.Errors = append(run.Errors, runErr)
+	}
+	if err := rows.Err(); err != nil {
+		http.Error(w, "Error fetching ingestion run", http.StatusInternalServerError)
+		log.Println("❌ Error reading ingestion run errors:", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(run)
+}
+
+// Start a bulk data refresh now. It runs in the background; follow it through /api/ingestion-runs.
+func TriggerBulkUpdate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := utils.StartBulkRefresh(DB, Source, utils.TriggerManual); err != nil {
+		if errors.Is(err, utils.ErrRefreshRunning) {
+			http.Error(w, "A bulk data refresh is already running", http.StatusConflict)
+			return
+		}
+		http.Error(w, "Error starting bulk data refresh", http.StatusInternalServerError)
+		log.Println("❌ Error starting bulk data refresh:", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string]string{"status": "started"})
+}
+
+func scanRun(row db.RowScanner) (models.IngestionRun, error) {
+	var run models.IngestionRun
+	var finishedAt sql.NullTime
+	err := row.Scan(&run.ID, &run.BulkType, &run.BulkDataID, &run.Source, &run.Trigger, &run.Status, &run.StartedAt, &finishedAt,
+		&run.Bytes, &run.CardsRead, &run.CardsAdded, &run.CardsChanged, &run.CardsRetired, &run.CardsRejected, &run.Error)
+	if finishedAt.Valid {
+		run.FinishedAt = &finishedAt.Time
+	}
+	return run, err
+}
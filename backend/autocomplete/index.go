@@ -0,0 +1,212 @@
+// Package autocomplete suggests card names as they are typed. Names are held in memory in indexes
+// of their normalized forms and of every suffix of those, each sorted, so the names starting with,
+// having a word starting with, or containing a query are all found by binary search without
+// touching the database.
+package autocomplete
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Color identity bits of an entry.
+const (
+	colorW uint8 = 1 << iota
+	colorU
+	colorB
+	colorR
+	colorG
+)
+
+var colorBits = map[rune]uint8{'W': colorW, 'U': colorU, 'B': colorB, 'R': colorR, 'G': colorG}
+
+// Card is a card as the index needs it.
+type Card struct {
+	Name          string
+	FaceNames     []string
+	ColorIdentity []string
+	LegalIn       []string // formats the card is legal in
+}
+
+// Ways a name can match a query, best first.
+const (
+	tierPrefix   = iota // the name starts with the query
+	tierWord            // a later word of the name starts with the query
+	tierContains        // the name contains the query inside a word
+	numTiers
+)
+
+// entry is one searchable suffix of a card name or, for multi-faced cards, of a face name, which
+// completes to the card's full name.
+type entry struct {
+	key  string // normalized name, from the start of the suffix on
+	card int
+}
+
+type indexedCard struct {
+	name     string
+	identity uint8
+	legal    map[string]bool
+}
+
+// Index is an immutable set of card names ready for completion.
+type Index struct {
+	tiers [numTiers][]entry // the suffixes matching in each tier, each sorted by key
+	cards []indexedCard
+}
+
+// Filter restricts completions. The zero Filter allows every card.
+type Filter struct {
+	LegalIn  string // only cards legal in this format, e.g. "commander"
+	Identity string // only cards whose color identity is within these colors, e.g. "WUB"
+}
+
+// NewIndex indexes cards.
+func NewIndex(cards []Card) *Index {
+	idx := &Index{cards: make([]indexedCard, len(cards))}
+	for i, c := range cards {
+		card := indexedCard{name: c.Name, identity: identityBits(strings.Join(c.ColorIdentity, "")), legal: make(map[string]bool, len(c.LegalIn))}
+		for _, format := range c.LegalIn {
+			card.legal[format] = true
+		}
+		idx.cards[i] = card
+
+		idx.add(Normalize(c.Name), i)
+		for _, face := range c.FaceNames {
+			if key := Normalize(face); key != "" && !strings.EqualFold(face, c.Name) {
+				idx.add(key, i)
+			}
+		}
+	}
+	for _, entries := range idx.tiers {
+		sort.Slice(entries, func(i, j int) bool {
+			if entries[i].key != entries[j].key {
+				return entries[i].key < entries[j].key
+			}
+			return entries[i].card < entries[j].card
+		})
+	}
+	return idx
+}
+
+// add indexes every suffix of the normalized name key of card under the tier a query matching
+// from there falls in. The suffixes share the memory of key.
+func (idx *Index) add(key string, card int) {
+	if key == "" {
+		return
+	}
+	idx.tiers[tierPrefix] = append(idx.tiers[tierPrefix], entry{key: key, card: card})
+	for i := 1; i < len(key); i++ {
+		if key[i] == ' ' || !utf8.RuneStart(key[i]) {
+			continue
+		}
+		tier := tierContains
+		if key[i-1] == ' ' {
+			tier = tierWord
+		}
+		idx.tiers[tier] = append(idx.tiers[tier], entry{key: key[i:], card: card})
+	}
+}
+
+// Len returns the number of cards in the index.
+func (idx *Index) Len() int { return len(idx.cards) }
+
+// Complete returns up to limit card names matching q, best first: names starting with q, then
+// names with a word starting with q, then names containing q anywhere, each group alphabetical.
+// Matching ignores case, accents and punctuation, and face names complete to their card's name. A
+// card is ranked by the best match among its card and face names.
+func (idx *Index) Complete(q string, f Filter, limit int) []string {
+	q = Normalize(q)
+	if q == "" || limit <= 0 {
+		return []string{}
+	}
+	identity := identityBits(f.Identity)
+
+	// Cards matched in a tier are skipped in the later ones, so each card lands in its best tier.
+	// The later tiers are only looked at while the earlier ones come up short.
+	seen := make(map[int]bool)
+	results := []string{}
+	for _, entries := range idx.tiers {
+		if len(results) >= limit {
+			break
+		}
+		// The suffixes starting with q are a contiguous run of the sorted entries
+		var names []string
+		start := sort.Search(len(entries), func(i int) bool { return entries[i].key >= q })
+		for i := start; i < len(entries) && strings.HasPrefix(entries[i].key, q); i++ {
+			card := entries[i].card
+			if seen[card] || !idx.allows(card, f, identity) {
+				continue
+			}
+			seen[card] = true
+			names = append(names, idx.cards[card].name)
+		}
+		sort.Strings(names)
+		results = append(results, names...)
+	}
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results
+}
+
+func (idx *Index) allows(card int, f Filter, identity uint8) bool {
+	c := idx.cards[card]
+	if f.LegalIn != "" && !c.legal[f.LegalIn] {
+		return false
+	}
+	if f.Identity != "" && c.identity&^identity != 0 {
+		return false
+	}
+	return true
+}
+
+// ValidIdentity reports whether s only holds the letters WUBRG (in any case), or C for colorless.
+func ValidIdentity(s string) bool {
+	for _, r := range strings.ToUpper(s) {
+		if _, ok := colorBits[r]; !ok && r != 'C' {
+			return false
+		}
+	}
+	return true
+}
+
+func identityBits(colors string) uint8 {
+	var bits uint8
+	for _, r := range strings.ToUpper(colors) {
+		bits |= colorBits[r]
+	}
+	return bits
+}
+
+// accentFolds spells out the letters with diacritics found in card names.
+var accentFolds = strings.NewReplacer(
+	"á", "a", "à", "a", "â", "a", "ä", "a", "ã", "a", "é", "e", "è", "e", "ê", "e", "ë", "e",
+	"í", "i", "ì", "i", "î", "i", "ï", "i", "ó", "o", "ò", "o", "ô", "o", "ö", "o", "õ", "o",
+	"ú", "u", "ù", "u", "û", "u", "ü", "u", "ñ", "n", "ç", "c", "æ", "ae",
+)
+
+// Normalize lower-cases s, folds accents, drops punctuation other than word separators and
+// collapses whitespace, so "Lim-Dûl's Vault" and "lim duls  vault" both become "lim duls vault".
+func Normalize(s string) string {
+	s = accentFolds.Replace(strings.ToLower(s))
+	var b strings.Builder
+	space := true // drop leading spaces
+	for _, r := range s {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			b.WriteRune(r)
+			space = false
+		case r == '\'' || r == '’' || r == ',' || r == '.' || r == '!' || r == '?' || r == '"':
+			// Dropped without a separator: "praetors'" matches "praetors"
+		default:
+			if !space {
+				b.WriteByte(' ')
+				space = true
+			}
+		}
+	}
+	return strings.TrimRight(b.String(), " ")
+}
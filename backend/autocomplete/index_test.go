@@ -0,0 +1,119 @@
+package autocomplete
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	for _, c := range []struct {
+		in, want string
+	}{
+		{"Lightning Bolt", "lightning bolt"},
+		{"Lim-Dûl's Vault", "lim duls vault"},
+		{"lim duls  vault", "lim duls vault"},
+		{"  Fire // Ice  ", "fire ice"},
+		{"Æther Vial", "aether vial"},
+		{"Praetors' Counsel", "praetors counsel"},
+		{"Séance", "seance"},
+		{"Borrowing 100,000 Arrows", "borrowing 100000 arrows"},
+		{"Who // What // When // Where // Why", "who what when where why"},
+		{"?!", ""},
+		{"", ""},
+	} {
+		if got := Normalize(c.in); got != c.want {
+			t.Errorf("Normalize(%q) = %q, want %q", c.in, got, c.want)
+		}
+	}
+}
+
+func TestCompleteRanksPrefixThenWordThenContains(t *testing.T) {
+	idx := NewIndex([]Card{
+		{Name: "Resolute Blademaster"},
+		{Name: "Sol Ring"},
+		{Name: "Ring of Sol"},
+		{Name: "Solemn Simulacrum"},
+		{Name: "Consolidate"},
+		{Name: "Llanowar Elves"},
+	})
+	want := []string{"Sol Ring", "Solemn Simulacrum", "Ring of Sol", "Consolidate", "Resolute Blademaster"}
+	if got := idx.Complete("SOL", Filter{}, 10); !reflect.DeepEqual(got, want) {
+		t.Errorf("Complete(SOL) = %q, want %q", got, want)
+	}
+	if got := idx.Complete("sol", Filter{}, 3); !reflect.DeepEqual(got, want[:3]) {
+		t.Errorf("Complete(sol) limited to 3 = %q, want %q", got, want[:3])
+	}
+	if got := idx.Complete("ring of", Filter{}, 10); !reflect.DeepEqual(got, []string{"Ring of Sol"}) {
+		t.Errorf("Complete(ring of) = %q, want [Ring of Sol]", got)
+	}
+	for _, q := range []string{"", "  ", "xyz"} {
+		if got := idx.Complete(q, Filter{}, 10); len(got) != 0 {
+			t.Errorf("Complete(%q) = %q, want no names", q, got)
+		}
+	}
+}
+
+// TestCompleteRanksCardsByTheirBestName checks a card is ranked by the best match among all its
+// names, not by whichever of them sorts first.
+func TestCompleteRanksCardsByTheirBestName(t *testing.T) {
+	idx := NewIndex([]Card{
+		// The face "Aftermath" only contains "math" and sorts first, but the face "Lost
+		// Mathematics" has a word starting with it
+		{Name: "Aftermath // Lost Mathematics", FaceNames: []string{"Aftermath", "Lost Mathematics"}},
+		{Name: "Zap Math"},
+		{Name: "Polymath"},
+	})
+	want := []string{"Aftermath // Lost Mathematics", "Zap Math", "Polymath"}
+	if got := idx.Complete("math", Filter{}, 10); !reflect.DeepEqual(got, want) {
+		t.Errorf("Complete(math) = %q, want %q", got, want)
+	}
+}
+
+func TestCompleteListsSplitCardsOnce(t *testing.T) {
+	idx := NewIndex([]Card{
+		{Name: "Fire // Ice", FaceNames: []string{"Fire", "Ice"}},
+		{Name: "Icy Manipulator"},
+	})
+	for q, want := range map[string][]string{
+		"fi":     {"Fire // Ice"},
+		"ice":    {"Fire // Ice"},
+		"ic":     {"Fire // Ice", "Icy Manipulator"},
+		"fire i": {"Fire // Ice"},
+	} {
+		if got := idx.Complete(q, Filter{}, 10); !reflect.DeepEqual(got, want) {
+			t.Errorf("Complete(%q) = %q, want %q", q, got, want)
+		}
+	}
+}
+
+func TestCompleteFilters(t *testing.T) {
+	idx := NewIndex([]Card{
+		{Name: "Sol Ring", LegalIn: []string{"commander", "vintage"}},
+		{Name: "Soldevi Adnate", ColorIdentity: []string{"B"}, LegalIn: []string{"commander", "legacy"}},
+		{Name: "Soltari Guerrillas", ColorIdentity: []string{"R", "W"}, LegalIn: []string{"legacy"}},
+		{Name: "Solitary Confinement", ColorIdentity: []string{"W"}, LegalIn: []string{"commander"}},
+	})
+	for _, c := range []struct {
+		filter Filter
+		want   []string
+	}{
+		{Filter{}, []string{"Sol Ring", "Soldevi Adnate", "Solitary Confinement", "Soltari Guerrillas"}},
+		{Filter{LegalIn: "commander"}, []string{"Sol Ring", "Soldevi Adnate", "Solitary Confinement"}},
+		{Filter{LegalIn: "modern"}, []string{}},
+		{Filter{Identity: "wr"}, []string{"Sol Ring", "Solitary Confinement", "Soltari Guerrillas"}},
+		{Filter{Identity: "C"}, []string{"Sol Ring"}},
+		{Filter{LegalIn: "legacy", Identity: "WUB"}, []string{"Soldevi Adnate"}},
+	} {
+		if got := idx.Complete("sol", c.filter, 10); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("Complete(sol, %+v) = %q, want %q", c.filter, got, c.want)
+		}
+	}
+}
+
+func TestValidIdentity(t *testing.T) {
+	for s, want := range map[string]bool{"": true, "WUBRG": true, "wub": true, "C": true, "X": false, "W U": false} {
+		if got := ValidIdentity(s); got != want {
+			t.Errorf("ValidIdentity(%q) = %v, want %v", s, got, want)
+		}
+	}
+}
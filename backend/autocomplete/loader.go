@@ -0,0 +1,120 @@
+package autocomplete
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync/atomic"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// pollInterval is how often the index checks for an oracle_cards ingestion newer than the one it
+// was built from. Ingestions may run in another backend instance, or in an -import process.
+const pollInterval = time.Minute
+
+var (
+	current atomic.Pointer[Index]
+	version atomic.Int64 // ID of the oracle_cards ingestion run the current index was built after
+	recheck = make(chan struct{}, 1)
+)
+
+// Current returns the latest index, or nil until the first one is built.
+func Current() *Index {
+	return current.Load()
+}
+
+// Invalidate asks the index to check for a new oracle_cards ingestion now rather than at the next
+// poll. It never blocks.
+func Invalidate() {
+	select {
+	case recheck <- struct{}{}:
+	default:
+	}
+}
+
+// Start builds the index from the live oracle cards in the background, then rebuilds it whenever
+// an oracle_cards ingestion succeeds, until ctx is cancelled. Current is nil until the first build.
+func Start(ctx context.Context, db *sql.DB) {
+	go func() {
+		refresh(ctx, db)
+		ticker := time.NewTicker(pollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			case <-recheck:
+			}
+			refresh(ctx, db)
+		}
+	}()
+}
+
+// refresh rebuilds the index if there is no index yet or oracle_cards was ingested since it was built.
+func refresh(ctx context.Context, db *sql.DB) {
+	var latest int64
+	err := db.QueryRowContext(ctx, `
+		SELECT COALESCE(max(id), 0) FROM ingestion_runs WHERE bulk_type = 'oracle_cards' AND status = 'succeeded'
+	`).Scan(&latest)
+	if err != nil {
+		log.Printf("⚠️ Error checking for new oracle cards: %v\n", err)
+		return
+	}
+	if current.Load() != nil && latest == version.Load() {
+		return
+	}
+
+	start := time.Now()
+	idx, err := Load(ctx, db)
+	if err != nil {
+		log.Printf("❌ Error building autocomplete index: %v\n", err)
+		return
+	}
+	current.Store(idx)
+	version.Store(latest)
+	log.Printf("🔤 Built autocomplete index of %d cards in %s\n", idx.Len(), time.Since(start).Round(time.Millisecond))
+}
+
+// Load builds an index of the live oracle cards, leaving out tokens, emblems and art cards.
+func Load(ctx context.Context, db *sql.DB) (*Index, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT name, COALESCE(color_identity, '{}'), COALESCE(legalities, '{}'), COALESCE(jsonb_path_query_array(card_faces, '$[*].name'), '[]')
+		FROM oracle_cards
+		WHERE retired_at IS NULL AND COALESCE(layout, '') NOT IN ('token', 'double_faced_token', 'emblem', 'art_series');
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("error loading card names: %w", err)
+	}
+	defer rows.Close()
+
+	var cards []Card
+	for rows.Next() {
+		var card Card
+		var legalitiesJSON, faceNamesJSON []byte
+		if err := rows.Scan(&card.Name, pq.Array(&card.ColorIdentity), &legalitiesJSON, &faceNamesJSON); err != nil {
+			return nil, fmt.Errorf("error scanning card name: %w", err)
+		}
+		var legalities map[string]string
+		if err := json.Unmarshal(legalitiesJSON, &legalities); err != nil {
+			return nil, fmt.Errorf("error decoding legalities of %s: %w", card.Name, err)
+		}
+		for format, status := range legalities {
+			if status == "legal" {
+				card.LegalIn = append(card.LegalIn, format)
+			}
+		}
+		if err := json.Unmarshal(faceNamesJSON, &card.FaceNames); err != nil {
+			return nil, fmt.Errorf("error decoding face names of %s: %w", card.Name, err)
+		}
+		cards = append(cards, card)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error loading card names: %w", err)
+	}
+	return NewIndex(cards), nil
+}
@@ -0,0 +1,59 @@
+package cards
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/quehorrifico/mana-tomb/backend/autocomplete"
+	"github.com/quehorrifico/mana-tomb/backend/middleware"
+)
+
+// Number of names Autocomplete returns unless ?limit= asks for another, up to the maximum.
+const (
+	defaultAutocompleteLimit = 20
+	maxAutocompleteLimit     = 50
+)
+
+// Complete a partly typed card name from the in-memory name index, without a database query. Names
+// starting with q come first, then names with a word starting with q, then names containing it.
+// ?legal=commander only suggests cards legal in a format, and ?identity=WUB only cards whose color
+// identity is within the given colors.
+func Autocomplete(w http.ResponseWriter, r *http.Request) {
+	middleware.EnableCORS(w)
+	if r.Method == "OPTIONS" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	limit := defaultAutocompleteLimit
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxAutocompleteLimit {
+			http.Error(w, fmt.Sprintf("limit must be between 1 and %d", maxAutocompleteLimit), http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+	filter := autocomplete.Filter{LegalIn: r.URL.Query().Get("legal"), Identity: r.URL.Query().Get("identity")}
+	if !autocomplete.ValidIdentity(filter.Identity) {
+		http.Error(w, "identity must only contain the colors W, U, B, R and G, or C for colorless", http.StatusBadRequest)
+		return
+	}
+
+	idx := autocomplete.Current()
+	if idx == nil {
+		http.Error(w, "Card names are still loading", http.StatusServiceUnavailable)
+		return
+	}
+	names := idx.Complete(r.URL.Query().Get("q"), filter, limit)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=60")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"object":       "catalog",
+		"total_values": len(names),
+		"data":         names,
+	})
+}
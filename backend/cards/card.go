@@ -0,0 +1,140 @@
+package cards
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/lib/pq"
+	"github.com/quehorrifico/mana-tomb/backend/db"
+	"github.com/quehorrifico/mana-tomb/backend/middleware"
+	"github.com/quehorrifico/mana-tomb/backend/models"
+)
+
+// cardColumns are every stored column of oracle_cards, in the order scanCard reads them. Queries
+// select them from oracle_cards unaliased, followed by any extra columns of their own.
+const cardColumns = `id, COALESCE(oracle_id::text, ''), multiverse_ids, COALESCE(mtgo_id, 0), COALESCE(mtgo_foil_id, 0),
+	COALESCE(tcgplayer_id, 0), COALESCE(cardmarket_id, 0), name, COALESCE(lang, ''), COALESCE(to_char(released_at, 'YYYY-MM-DD'), ''),
+	COALESCE(uri, ''), COALESCE(scryfall_uri, ''), COALESCE(layout, ''), COALESCE(highres_image, FALSE), COALESCE(image_status, ''),
+	image_uris, COALESCE(mana_cost, ''), COALESCE(cmc, 0), COALESCE(type_line, ''), COALESCE(oracle_text, ''), colors, color_identity,
+	keywords, legalities, games, COALESCE(reserved, FALSE), COALESCE(game_changer, FALSE), COALESCE(foil, FALSE), COALESCE(nonfoil, FALSE),
+	finishes, COALESCE(oversized, FALSE), COALESCE(promo, FALSE), COALESCE(reprint, FALSE), COALESCE(variation, FALSE),
+	COALESCE(set_id::text, ''), COALESCE(set, ''), COALESCE(set_name, ''), COALESCE(set_type, ''), COALESCE(set_uri, ''),
+	COALESCE(set_search_uri, ''), COALESCE(scryfall_set_uri, ''), COALESCE(rulings_uri, ''), COALESCE(prints_search_uri, ''),
+	COALESCE(collector_number, ''), COALESCE(digital, FALSE), COALESCE(rarity, ''), COALESCE(flavor_text, ''),
+	COALESCE(card_back_id::text, ''), COALESCE(artist, ''), artist_ids, COALESCE(illustration_id::text, ''), COALESCE(border_color, ''),
+	COALESCE(frame, ''), COALESCE(full_art, FALSE), COALESCE(textless, FALSE), COALESCE(booster, FALSE), COALESCE(story_spotlight, FALSE),
+	COALESCE(edhrec_rank, 0), prices, card_faces`
+
+// scanCard reads a row of cardColumns, then any extra columns into extra, into the card returned
+// by the API: its JSONB columns decoded and its mana parsed.
+func scanCard(row db.RowScanner, extra ...any) (cardResponse, error) {
+	var card models.OracleCard
+	var multiverseIDs pq.Int64Array
+	var imageURIsJSON, legalitiesJSON, pricesJSON, cardFacesJSON []byte
+	dest := []any{
+		&card.ID, &card.OracleID, &multiverseIDs, &card.MTGOID, &card.MTGOFoilID,
+		&card.TCGPlayerID, &card.CardMarketID, &card.Name, &card.Lang, &card.ReleasedAt,
+		&card.URI, &card.ScryfallURI, &card.Layout, &card.HighResImage, &card.ImageStatus,
+		&imageURIsJSON, &card.ManaCost, &card.CMC, &card.TypeLine, &card.OracleText, pq.Array(&card.Colors), pq.Array(&card.ColorIdentity),
+		pq.Array(&card.Keywords), &legalitiesJSON, pq.Array(&card.Games), &card.Reserved, &card.GameChanger, &card.Foil, &card.NonFoil,
+		pq.Array(&card.Finishes), &card.Oversized, &card.Promo, &card.Reprint, &card.Variation,
+		&card.SetID, &card.Set, &card.SetName, &card.SetType, &card.SetURI,
+		&card.SetSearchURI, &card.ScryfallSetURI, &card.RulingsURI, &card.PrintsSearchURI,
+		&card.CollectorNumber, &card.Digital, &card.Rarity, &card.FlavorText,
+		&card.CardBackID, &card.Artist, pq.Array(&card.ArtistIDs), &card.IllustrationID, &card.BorderColor,
+		&card.Frame, &card.FullArt, &card.Textless, &card.Booster, &card.StorySpotlight,
+		&card.EDHRecRank, &pricesJSON, &cardFacesJSON,
+	}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return cardResponse{}, err
+	}
+
+	for _, id := range multiverseIDs {
+		card.MultiverseIDs = append(card.MultiverseIDs, int(id))
+	}
+	// Any JSONB column may be NULL, e.g. image_uris of a double-faced card
+	for _, col := range []struct {
+		name string
+		raw  []byte
+		dest any
+	}{
+		{"image URIs", imageURIsJSON, &card.ImageURIs},
+		{"legalities", legalitiesJSON, &card.Legalities},
+		{"prices", pricesJSON, &card.Prices},
+		{"card faces", cardFacesJSON, &card.CardFaces},
+	} {
+		if len(col.raw) == 0 {
+			continue
+		}
+		if err := json.Unmarshal(col.raw, col.dest); err != nil {
+			return cardResponse{}, fmt.Errorf("error decoding %s of card %s: %w", col.name, card.ID, err)
+		}
+	}
+	return newCardResponse(card), nil
+}
+
+// Get the complete stored card by Scryfall ID. The ID of any printing of a card finds its oracle card.
+func GetCardByID(w http.ResponseWriter, r *http.Request) {
+	middleware.EnableCORS(w)
+	if r.Method == "OPTIONS" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	id := r.PathValue("id")
+	if !db.UUIDPattern.MatchString(id) {
+		http.Error(w, "Card ID must be a Scryfall ID", http.StatusBadRequest)
+		return
+	}
+	card, err := scanCard(DB.QueryRow(`
+		SELECT `+cardColumns+`
+		FROM oracle_cards
+		WHERE retired_at IS NULL
+		AND (id = $1 OR oracle_id = (SELECT oracle_id FROM printings WHERE id = $1 AND retired_at IS NULL))
+		ORDER BY id = $1 DESC
+		LIMIT 1;
+	`, id))
+	writeCard(w, card, err)
+}
+
+// Get the complete stored card by oracle ID, which all printings of the card share
+func GetCardByOracleID(w http.ResponseWriter, r *http.Request) {
+	middleware.EnableCORS(w)
+	if r.Method == "OPTIONS" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	oracleID := r.PathValue("oracle_id")
+	if !db.UUIDPattern.MatchString(oracleID) {
+		http.Error(w, "Oracle ID must be a UUID", http.StatusBadRequest)
+		return
+	}
+	card, err := scanCard(DB.QueryRow(`
+		SELECT `+cardColumns+`
+		FROM oracle_cards
+		WHERE oracle_id = $1 AND retired_at IS NULL
+		LIMIT 1;
+	`, oracleID))
+	writeCard(w, card, err)
+}
+
+// writeCard answers a card detail request with the card, or 404 if it was not found.
+func writeCard(w http.ResponseWriter, card cardResponse, err error) {
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "Card not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Error fetching card", http.StatusInternalServerError)
+		log.Println("❌ Error fetching card:", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(card)
+}
@@ -0,0 +1,200 @@
+package cards
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/lib/pq"
+	"github.com/quehorrifico/mana-tomb/backend/db"
+	"github.com/quehorrifico/mana-tomb/backend/middleware"
+)
+
+// maxCollectionIdentifiers is the most cards one collection request can ask for: a Commander deck
+// with room to spare for its sideboard and maybeboard.
+const maxCollectionIdentifiers = 500
+
+// maxCollectionBodyBytes bounds the request body of a collection request.
+const maxCollectionBodyBytes = 1 << 20
+
+// cardIdentifier identifies one card of a collection request by exactly one of its name (or a face
+// name), a Scryfall ID of any of its printings, its oracle ID, or a set code and collector number.
+type cardIdentifier struct {
+	Name            string `json:"name,omitempty"`
+	ID              string `json:"id,omitempty"`
+	OracleID        string `json:"oracle_id,omitempty"`
+	Set             string `json:"set,omitempty"`
+	CollectorNumber string `json:"collector_number,omitempty"`
+}
+
+// Kinds of card identifier. A resolved card is keyed by kind:value.
+const (
+	byName     = "name"
+	byID       = "id"
+	byOracleID = "oracle_id"
+	bySet      = "set"
+)
+
+// lookup returns the kind of the identifier and the value it is matched on, or ok false unless it
+// has exactly one kind.
+func (id cardIdentifier) lookup() (kind, value string, ok bool) {
+	var kinds []string
+	if id.Name != "" {
+		kinds, kind, value = append(kinds, byName), byName, strings.ToLower(strings.TrimSpace(id.Name))
+	}
+	if id.ID != "" {
+		kinds, kind, value = append(kinds, byID), byID, strings.ToLower(id.ID)
+	}
+	if id.OracleID != "" {
+		kinds, kind, value = append(kinds, byOracleID), byOracleID, strings.ToLower(id.OracleID)
+	}
+	if id.Set != "" || id.CollectorNumber != "" {
+		if id.Set == "" || id.CollectorNumber == "" {
+			return "", "", false
+		}
+		kinds, kind, value = append(kinds, bySet), bySet, strings.ToLower(id.Set)+"/"+id.CollectorNumber
+	}
+	return kind, value, len(kinds) == 1
+}
+
+// collectionQueries select, for each kind of identifier, the values they resolve and the oracle
+// card each resolves to, with whether it is only a fallback match. They are joined to the live
+// oracle cards by collectionQuery.
+var collectionQueries = map[string]string{
+	// A card's full name beats a face name, e.g. "Fire" of Fire // Ice. Each branch can use an
+	// index: lower(name) for the first and the lowercased face names for the second.
+	byName: `
+		SELECT lower(o.name), o.id, FALSE
+		FROM oracle_cards o
+		WHERE lower(o.name) = ANY($1) AND ((o.oracle_text IS NOT NULL AND o.oracle_text <> '') OR jsonb_array_length(o.card_faces) > 0)
+		UNION
+		SELECT lower(face->>'name'), o.id, lower(face->>'name') <> lower(o.name)
+		FROM oracle_cards o
+		CROSS JOIN jsonb_array_elements(o.card_faces) AS face
+		WHERE jsonb_array_length(o.card_faces) > 0 AND ` + lowerFaceNamesExpr + ` ?| $1::text[]
+		AND lower(face->>'name') = ANY($1)`,
+	// The oracle card's own Scryfall ID beats another printing's
+	byID: `
+		SELECT id::text, id, FALSE FROM oracle_cards WHERE id = ANY($1::uuid[])
+		UNION ALL
+		SELECT p.id::text, o.id, TRUE
+		FROM printings p
+		JOIN oracle_cards o ON o.oracle_id = p.oracle_id
+		WHERE p.id = ANY($1::uuid[]) AND p.retired_at IS NULL`,
+	byOracleID: `
+		SELECT oracle_id::text, id, FALSE FROM oracle_cards WHERE oracle_id = ANY($1::uuid[])`,
+	// Values are "set/collector_number"; set codes never contain a slash
+	bySet: `
+		SELECT v.value, o.id, FALSE
+		FROM unnest($1::text[]) AS v (value)
+		JOIN printings p ON p.set = split_part(v.value, '/', 1) AND p.collector_number = substr(v.value, strpos(v.value, '/') + 1)
+		JOIN oracle_cards o ON o.oracle_id = p.oracle_id
+		WHERE p.retired_at IS NULL`,
+}
+
+// collectionQuery selects the live oracle cards matched by one of collectionQueries, best match
+// of each value first.
+const collectionQuery = `
+	SELECT ` + cardColumns + `, m.value
+	FROM (%s) AS m (value, card_id, fallback)
+	JOIN oracle_cards ON oracle_cards.id = m.card_id AND oracle_cards.retired_at IS NULL
+	ORDER BY m.value, m.fallback, ` + hasImageCondition + ` DESC;
+`
+
+// Resolve many cards at once, e.g. every card of a deck, from a mix of names, Scryfall IDs, oracle
+// IDs and set and collector numbers. The cards found are returned in the order they were asked
+// for, and the identifiers that matched no card in not_found.
+func GetCardCollection(w http.ResponseWriter, r *http.Request) {
+	middleware.EnableCORS(w)
+	if r.Method == http.MethodOptions {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request struct {
+		Identifiers []cardIdentifier `json:"identifiers"`
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxCollectionBodyBytes)).Decode(&request); err != nil {
+		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		return
+	}
+	if len(request.Identifiers) == 0 || len(request.Identifiers) > maxCollectionIdentifiers {
+		http.Error(w, fmt.Sprintf("identifiers must hold between 1 and %d cards", maxCollectionIdentifiers), http.StatusBadRequest)
+		return
+	}
+
+	// Collect the distinct values to look up for each kind of identifier
+	values := make(map[string][]string)
+	seen := make(map[string]bool)
+	for i, id := range request.Identifiers {
+		kind, value, ok := id.lookup()
+		if !ok {
+			http.Error(w, fmt.Sprintf("identifiers[%d] must have exactly one of name, id, oracle_id, or set and collector_number", i), http.StatusBadRequest)
+			return
+		}
+		// A malformed ID matches no card, and would fail the whole query if cast to UUID
+		if (kind == byID || kind == byOracleID) && !db.UUIDPattern.MatchString(value) {
+			continue
+		}
+		if !seen[kind+":"+value] {
+			seen[kind+":"+value] = true
+			values[kind] = append(values[kind], value)
+		}
+	}
+
+	found := make(map[string]cardResponse)
+	for kind, list := range values {
+		if err := resolveCards(kind, list, found); err != nil {
+			http.Error(w, "Error fetching cards", http.StatusInternalServerError)
+			log.Println("❌ Error resolving card collection:", err)
+			return
+		}
+	}
+
+	cards := []cardResponse{}
+	notFound := []cardIdentifier{}
+	for _, id := range request.Identifiers {
+		kind, value, _ := id.lookup()
+		if card, ok := found[kind+":"+value]; ok {
+			cards = append(cards, card)
+		} else {
+			notFound = append(notFound, id)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"object":    "list",
+		"data":      cards,
+		"not_found": notFound,
+	})
+}
+
+// resolveCards looks up values of one kind of identifier, adding the best card for each value to
+// found under the key kind:value.
+func resolveCards(kind string, values []string, found map[string]cardResponse) error {
+	rows, err := DB.Query(fmt.Sprintf(collectionQuery, collectionQueries[kind]), pq.Array(values))
+	if err != nil {
+		return fmt.Errorf("error looking up cards by %s: %w", kind, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var value string
+		card, err := scanCard(rows, &value)
+		if err != nil {
+			return fmt.Errorf("error scanning card: %w", err)
+		}
+		key := kind + ":" + value
+		if _, ok := found[key]; !ok {
+			found[key] = card // only the best match of each value is kept
+		}
+	}
+	return rows.Err()
+}
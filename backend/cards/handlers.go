@@ -7,11 +7,21 @@ import (
 	"net/http"
 
 	"github.com/quehorrifico/mana-tomb/backend/middleware"
-	"github.com/quehorrifico/mana-tomb/backend/models"
+	"github.com/quehorrifico/mana-tomb/backend/search"
 )
 
 var DB *sql.DB
 
+// lowerFaceNamesExpr is the lowercased names of a card's faces, a JSON array, as indexed by
+// oracle_cards_lower_face_names_idx for the cards with faces.
+const lowerFaceNamesExpr = `jsonb_path_query_array(lower(card_faces::text)::jsonb, '$[*].name')`
+
+// faceNameCondition matches $1, ignoring case, against the name of any face of a multi-faced card.
+const faceNameCondition = `(jsonb_array_length(card_faces) > 0 AND ` + lowerFaceNamesExpr + ` ? lower($1))`
+
+// hasImageCondition is true for cards with a top-level image or, for double-faced cards, a front face image.
+const hasImageCondition = `(COALESCE(image_uris->>'normal', '') <> '' OR COALESCE(card_faces->0->'image_uris'->>'normal', '') <> '')`
+
 // Get a random card from the database
 func GetRandomCard(w http.ResponseWriter, r *http.Request) {
 	middleware.EnableCORS(w) // Add CORS headers
@@ -22,30 +32,21 @@ func GetRandomCard(w http.ResponseWriter, r *http.Request) {
 
 	// Use the existing db connection
 	query := `
-		SELECT name, mana_cost, image_uris, type_line, oracle_text, set, set_name, set_uri, set_id, set_type, set_search_uri, scryfall_set_uri
+		SELECT ` + cardColumns + `
 		FROM oracle_cards
-		ORDER BY random(), (SELECT COUNT(*) FROM jsonb_object_keys(image_uris)) DESC
+		WHERE retired_at IS NULL AND ` + hasImageCondition + `
+		ORDER BY random()
 		LIMIT 1;
 	`
 
-	var card models.OracleCard
-	var imageURIsJSON []byte
-
 	// Fetch a random card
-	err := DB.QueryRow(query).Scan(&card.Name, &card.ManaCost, &imageURIsJSON, &card.TypeLine, &card.OracleText, &card.Set, &card.SetName, &card.SetURI, &card.SetID, &card.SetType, &card.SetSearchURI, &card.ScryfallSetURI)
+	card, err := scanCard(DB.QueryRow(query))
 	if err != nil {
 		http.Error(w, "Error fetching card", http.StatusInternalServerError)
 		log.Println("❌ Error fetching random card:", err)
 		return
 	}
 
-	// Random card found, return it
-	if err := json.Unmarshal(imageURIsJSON, &card.ImageURIs); err != nil {
-		http.Error(w, "Error decoding image URIs", http.StatusInternalServerError)
-		log.Println("❌ Error decoding image URIs:", err)
-		return
-	}
-
 	// Send the single exact match with a flag
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
@@ -69,82 +70,146 @@ func GetCardByName(w http.ResponseWriter, r *http.Request) {
 
 	// Try finding an **exact match** first
 	queryExact := `
-		SELECT name, mana_cost, image_uris, type_line, oracle_text, set, set_name, set_uri, set_id, set_type, set_search_uri, scryfall_set_uri
+		SELECT ` + cardColumns + `
 		FROM oracle_cards
-		WHERE name ILIKE $1
-		AND oracle_text IS NOT NULL AND oracle_text <> ''
-		ORDER BY (SELECT COUNT(*) FROM jsonb_object_keys(image_uris)) DESC
+		WHERE (lower(name) = lower($1) OR ` + faceNameCondition + `)
+		AND ((oracle_text IS NOT NULL AND oracle_text <> '') OR jsonb_array_length(card_faces) > 0)
+		AND retired_at IS NULL
+		ORDER BY lower(name) = lower($1) DESC, ` + hasImageCondition + ` DESC
 		LIMIT 1;
 	`
-	var exactMatch models.OracleCard
-	var imageURIsJSON []byte
-	err := DB.QueryRow(queryExact, cardName).Scan(
-		&exactMatch.Name, &exactMatch.ManaCost, &imageURIsJSON, &exactMatch.TypeLine, &exactMatch.OracleText,
-		&exactMatch.Set, &exactMatch.SetName, &exactMatch.SetURI, &exactMatch.SetID, &exactMatch.SetType,
-		&exactMatch.SetSearchURI, &exactMatch.ScryfallSetURI,
-	)
+	exactMatch, err := scanCard(DB.QueryRow(queryExact, cardName))
+
+	// Then a name as printed on a non-English printing, e.g. /card/Blitzschlag
+	lang := r.URL.Query().Get("lang")
+	var matchedLang string
+	if err == sql.ErrNoRows {
+		exactMatch, err = scanCard(DB.QueryRow(queryPrintedName, cardName, lang), &matchedLang)
+	}
+	if err != nil && err != sql.ErrNoRows {
+		http.Error(w, "Error fetching card", http.StatusInternalServerError)
+		log.Println("❌ Error fetching card:", err)
+		return
+	}
 	if err == nil {
 		// Exact match found, return it
-		if err := json.Unmarshal(imageURIsJSON, &exactMatch.ImageURIs); err != nil {
-			http.Error(w, "Error decoding image URIs", http.StatusInternalServerError)
-			log.Println("❌ Error decoding image URIs:", err)
-			return
+		response := map[string]interface{}{
+			"exact_match": exactMatch,
+		}
+		if matchedLang != "" {
+			response["matched_lang"] = matchedLang
+		}
+
+		// Optionally include the card's rulings, e.g. /card/Lightning Bolt?include=rulings
+		if includes(r, "rulings") && exactMatch.OracleID != "" {
+			rulings, err := loadRulings(exactMatch.OracleID)
+			if err != nil {
+				http.Error(w, "Error fetching rulings", http.StatusInternalServerError)
+				log.Println("❌ Error fetching rulings:", err)
+				return
+			}
+			response["rulings"] = rulings
+		}
+
+		// Optionally include the printing in the requested or matched language, e.g.
+		// /card/Lightning Bolt?include=localized&lang=ja or /card/Rayo?include=localized
+		if lang == "" {
+			lang = matchedLang
+		}
+		if includes(r, "localized") && lang != "" && exactMatch.OracleID != "" {
+			printing, err := loadLocalizedPrinting(exactMatch.OracleID, lang)
+			if err != nil {
+				http.Error(w, "Error fetching localized printing", http.StatusInternalServerError)
+				log.Println("❌ Error fetching localized printing:", err)
+				return
+			}
+			if printing != nil {
+				response["localized_printing"] = printing
+			}
 		}
 
 		// Send the single exact match with a flag
 		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(map[string]interface{}{
-			"exact_match": exactMatch,
-		})
+		json.NewEncoder(w).Encode(response)
 		return
 	}
 
-	// If no exact match, return **fuzzy matches**
-	queryFuzzy := `
-		SELECT name, mana_cost, image_uris, type_line, oracle_text, set, set_name, set_uri, set_id, set_type, set_search_uri, scryfall_set_uri
-		FROM oracle_cards
-		WHERE name ILIKE '%' || $1 || '%'
-		LIMIT 10;
-	`
-	rows, err := DB.Query(queryFuzzy, cardName)
+	// If no exact match, return **fuzzy matches**, a page at a time. Multi-faced cards are named
+	// "Front // Back", so matching the full name also matches any face name.
+	where, args, err := search.Compile(&search.Term{Value: cardName}, nil)
+	if err != nil {
+		http.Error(w, "Error fetching cards", http.StatusInternalServerError)
+		log.Println("❌ Error compiling name search:", err)
+		return
+	}
+	params, err := parsePageParams(r, "name:"+cardName, fuzzyMatchPageSize, "name")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	threshold, err := similarityThreshold(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	page, err := queryCardPage(cardQuery{where: where, args: args}, params)
 	if err != nil {
 		http.Error(w, "Error fetching cards", http.StatusInternalServerError)
 		log.Println("❌ Error fetching cards:", err)
 		return
 	}
-	defer rows.Close()
-
-	var cards []models.OracleCard
-	for rows.Next() {
-		var card models.OracleCard
-		err := rows.Scan(
-			&card.Name, &card.ManaCost, &imageURIsJSON, &card.TypeLine, &card.OracleText,
-			&card.Set, &card.SetName, &card.SetURI, &card.SetID, &card.SetType,
-			&card.SetSearchURI, &card.ScryfallSetURI,
-		)
-		if err != nil {
-			log.Println("❌ Error scanning card:", err)
-			continue
-		}
 
-		// Convert JSONB data from database into Go struct
-		if err := json.Unmarshal(imageURIsJSON, &card.ImageURIs); err != nil {
-			log.Println("❌ Error decoding image URIs:", err)
-			continue
-		}
-
-		cards = append(cards, card)
+	// Suggest the closest name, which is all we have when the name is misspelled. When names do
+	// contain it, the substring matches are the results and the closest name is only a hint
+	similarLimit := params.size
+	if page.total > 0 {
+		similarLimit = 1
+	}
+	similar, suggestions, err := similarCards(cardName, threshold, similarLimit)
+	if err != nil {
+		http.Error(w, "Error fetching cards", http.StatusInternalServerError)
+		log.Println("❌ Error fetching similar cards:", err)
+		return
+	}
+	var didYouMean interface{}
+	if len(suggestions) > 0 {
+		didYouMean = suggestions[0].Name
 	}
 
-	// If no fuzzy matches found
-	if len(cards) == 0 {
-		http.Error(w, "No cards found", http.StatusNotFound)
+	// If no fuzzy or similar matches found
+	if page.total == 0 && len(similar) == 0 {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"error":        "No cards found",
+			"did_you_mean": nil,
+			"suggestions":  []nameSuggestion{},
+		})
 		return
 	}
 
+	response := map[string]interface{}{
+		"match_type":    "substring",
+		"fuzzy_matches": page.cards,
+		"order":         params.sort.Field,
+		"dir":           params.sort.Dir(),
+		"total_cards":   page.total,
+		"page_size":     params.size,
+		"has_more":      page.hasMore,
+		"next_cursor":   page.nextCursor,
+		"did_you_mean":  didYouMean,
+		"suggestions":   suggestions,
+	}
+	if page.total == 0 {
+		// Nothing contains the name as typed; the similar names, best first, are the matches
+		response["match_type"] = "similarity"
+		response["fuzzy_matches"] = similar
+		response["order"], response["dir"] = "similarity", "desc"
+		response["total_cards"] = len(similar)
+		response["has_more"], response["next_cursor"] = false, ""
+	}
+
 	// Convert to JSON and return response
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"fuzzy_matches": cards,
-	})
+	json.NewEncoder(w).Encode(response)
 }
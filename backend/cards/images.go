@@ -0,0 +1,97 @@
+package cards
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+	"os"
+
+	"github.com/quehorrifico/mana-tomb/backend/db"
+	"github.com/quehorrifico/mana-tomb/backend/imagecache"
+	"github.com/quehorrifico/mana-tomb/backend/middleware"
+)
+
+// Images is the local card image cache, set at startup.
+var Images *imagecache.Cache
+
+// Get the image of a card by its Scryfall ID, e.g. /card/{id}/image/normal. Cached images are
+// served from disk; anything else redirects to Scryfall's CDN, and the image is cached in the
+// background if its size is one the cache keeps.
+func GetCardImage(w http.ResponseWriter, r *http.Request) {
+	middleware.EnableCORS(w)
+	if r.Method == "OPTIONS" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	id, size := r.PathValue("id"), r.PathValue("size")
+	if !db.UUIDPattern.MatchString(id) {
+		http.Error(w, "Card ID must be a Scryfall ID", http.StatusBadRequest)
+		return
+	}
+	if !imagecache.IsKnownSize(size) {
+		http.Error(w, "Unknown image size", http.StatusBadRequest)
+		return
+	}
+
+	if Images != nil && Images.Caches(size) {
+		img, err := Images.Lookup(r.Context(), DB, id, size)
+		if err == nil {
+			if serveCachedImage(w, r, img) {
+				return
+			}
+		} else if err != imagecache.ErrNotCached {
+			log.Println("⚠️ Error looking up cached image:", err)
+		}
+	}
+
+	// Double-faced cards have no top-level image_uris; fall back to the front face
+	var url sql.NullString
+	err := DB.QueryRow(`
+		SELECT COALESCE(NULLIF(image_uris->>$2, ''), card_faces->0->'image_uris'->>$2)
+		FROM (
+			SELECT image_uris, card_faces FROM printings WHERE id = $1
+			UNION ALL
+			SELECT image_uris, card_faces FROM oracle_cards WHERE id = $1
+			UNION ALL
+			SELECT image_uris, card_faces FROM unique_artwork WHERE id = $1
+		) c
+		LIMIT 1;
+	`, id, size).Scan(&url)
+	if err == sql.ErrNoRows {
+		http.Error(w, "Card not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, "Error fetching card image", http.StatusInternalServerError)
+		log.Println("❌ Error fetching card image URL:", err)
+		return
+	}
+	if !url.Valid || url.String == "" {
+		http.Error(w, "Card has no image of that size", http.StatusNotFound)
+		return
+	}
+
+	if Images != nil && Images.Caches(size) {
+		Images.EnsureInBackground(DB, id, size, url.String, func(err error) {
+			log.Printf("⚠️ Error caching %s image of %s: %v\n", size, id, err)
+		})
+	}
+	http.Redirect(w, r, url.String, http.StatusFound)
+}
+
+// serveCachedImage writes a cached image, reporting false if its file has gone missing.
+func serveCachedImage(w http.ResponseWriter, r *http.Request, img imagecache.Image) bool {
+	f, err := os.Open(img.Path)
+	if err != nil {
+		log.Println("⚠️ Cached image file missing:", err)
+		return false
+	}
+	defer f.Close()
+
+	// Files are content-addressed, so a cached image never changes under its ETag
+	w.Header().Set("Content-Type", img.ContentType)
+	w.Header().Set("ETag", `"`+img.SHA256+`"`)
+	w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	http.ServeContent(w, r, "", img.FetchedAt, f)
+	return true
+}
@@ -0,0 +1,54 @@
+package cards
+
+import (
+	"database/sql"
+	"net/http"
+	"strings"
+)
+
+// queryPrintedName resolves $1 as the printed name, or a printed face name, of a non-English
+// printing to that printing's oracle card, preferring printings in language $2 and then the most
+// recent printing. The language of the matched printing is selected last.
+const queryPrintedName = `
+	SELECT ` + cardColumns + `, m.matched_lang
+	FROM oracle_cards
+	JOIN (
+		SELECT p.oracle_id, p.lang, p.released_at
+		FROM printings p
+		WHERE p.retired_at IS NULL AND p.lang <> 'en'
+		AND (lower(p.printed_name) = lower($1)
+			OR EXISTS (SELECT 1 FROM jsonb_array_elements(p.card_faces) AS face WHERE lower(face->>'printed_name') = lower($1)))
+	) AS m (matched_oracle_id, matched_lang, matched_released_at) ON oracle_cards.oracle_id = m.matched_oracle_id
+	WHERE oracle_cards.retired_at IS NULL
+	ORDER BY m.matched_lang = $2 DESC, m.matched_released_at DESC NULLS LAST
+	LIMIT 1;
+`
+
+// loadLocalizedPrinting returns the most recent printing of a card in lang, or nil if the card was
+// never printed in that language.
+func loadLocalizedPrinting(oracleID, lang string) (*PrintingResponse, error) {
+	p, err := ScanPrinting(DB.QueryRow(`
+		SELECT `+PrintingColumns+`
+		FROM printings
+		WHERE oracle_id = $1 AND lang = $2 AND retired_at IS NULL
+		ORDER BY released_at DESC NULLS LAST, id
+		LIMIT 1;
+	`, oracleID, lang))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// includes reports whether the comma-separated include parameter of r lists name.
+func includes(r *http.Request, name string) bool {
+	for _, v := range strings.Split(r.URL.Query().Get("include"), ",") {
+		if strings.TrimSpace(v) == name {
+			return true
+		}
+	}
+	return false
+}
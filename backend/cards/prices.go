@@ -0,0 +1,137 @@
+package cards
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/quehorrifico/mana-tomb/backend/db"
+	"github.com/quehorrifico/mana-tomb/backend/middleware"
+	"github.com/quehorrifico/mana-tomb/backend/models"
+)
+
+// defaultPriceWindow is how far back a price series goes when no "from" date is given.
+const defaultPriceWindow = 90 * 24 * time.Hour
+
+// Get the daily price history of a printing by its Scryfall ID, e.g.
+// /card/{id}/prices?from=2025-01-01&to=2025-03-31 (dates are inclusive, YYYY-MM-DD)
+func GetCardPrices(w http.ResponseWriter, r *http.Request) {
+	middleware.EnableCORS(w)
+	if r.Method == "OPTIONS" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	id := r.PathValue("id")
+	if !db.UUIDPattern.MatchString(id) {
+		http.Error(w, "Card ID must be a Scryfall ID", http.StatusBadRequest)
+		return
+	}
+
+	to := time.Now().UTC()
+	if v := r.URL.Query().Get("to"); v != "" {
+		parsed, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			http.Error(w, "Invalid 'to' date, expected YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+		to = parsed
+	}
+	from := to.Add(-defaultPriceWindow)
+	if v := r.URL.Query().Get("from"); v != "" {
+		parsed, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			http.Error(w, "Invalid 'from' date, expected YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+		from = parsed
+	}
+	if from.After(to) {
+		http.Error(w, "'from' must not be after 'to'", http.StatusBadRequest)
+		return
+	}
+
+	rows, err := DB.Query(`
+		SELECT to_char(snapshot_date, 'YYYY-MM-DD'), usd, usd_foil, usd_etched, eur, eur_foil, tix
+		FROM price_history
+		WHERE printing_id = $1 AND snapshot_date BETWEEN $2 AND $3
+		ORDER BY snapshot_date;
+	`, id, from.Format("2006-01-02"), to.Format("2006-01-02"))
+	if err != nil {
+		http.Error(w, "Error fetching prices", http.StatusInternalServerError)
+		log.Println("❌ Error fetching prices:", err)
+		return
+	}
+	defer rows.Close()
+
+	series := []models.PriceSnapshot{}
+	for rows.Next() {
+		var snapshot models.PriceSnapshot
+		var usd, usdFoil, usdEtched, eur, eurFoil, tix sql.NullFloat64
+		if err := rows.Scan(&snapshot.Date, &usd, &usdFoil, &usdEtched, &eur, &eurFoil, &tix); err != nil {
+			http.Error(w, "Error fetching prices", http.StatusInternalServerError)
+			log.Println("❌ Error scanning price snapshot:", err)
+			return
+		}
+		snapshot.USD, snapshot.USDFoil, snapshot.USDEtched = floatPtr(usd), floatPtr(usdFoil), floatPtr(usdEtched)
+		snapshot.EUR, snapshot.EURFoil, snapshot.Tix = floatPtr(eur), floatPtr(eurFoil), floatPtr(tix)
+		series = append(series, snapshot)
+	}
+	if err := rows.Err(); err != nil {
+		http.Error(w, "Error fetching prices", http.StatusInternalServerError)
+		log.Println("❌ Error reading price snapshots:", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"printing_id": id,
+		"from":        from.Format("2006-01-02"),
+		"to":          to.Format("2006-01-02"),
+		"series":      series,
+		"summary": map[string]models.PriceTrend{
+			"usd":        priceTrend(series, func(s models.PriceSnapshot) *float64 { return s.USD }),
+			"usd_foil":   priceTrend(series, func(s models.PriceSnapshot) *float64 { return s.USDFoil }),
+			"usd_etched": priceTrend(series, func(s models.PriceSnapshot) *float64 { return s.USDEtched }),
+			"eur":        priceTrend(series, func(s models.PriceSnapshot) *float64 { return s.EUR }),
+			"eur_foil":   priceTrend(series, func(s models.PriceSnapshot) *float64 { return s.EURFoil }),
+			"tix":        priceTrend(series, func(s models.PriceSnapshot) *float64 { return s.Tix }),
+		},
+	})
+}
+
+// priceTrend summarises the prices picked from each snapshot, ignoring days without a price.
+func priceTrend(series []models.PriceSnapshot, pick func(models.PriceSnapshot) *float64) models.PriceTrend {
+	var trend models.PriceTrend
+	for _, snapshot := range series {
+		price := pick(snapshot)
+		if price == nil {
+			continue
+		}
+		if trend.First == nil {
+			trend.First = price
+		}
+		trend.Last = price
+		if trend.Min == nil || *price < *trend.Min {
+			trend.Min = price
+		}
+		if trend.Max == nil || *price > *trend.Max {
+			trend.Max = price
+		}
+	}
+	if trend.First != nil && *trend.First != 0 {
+		change := math.Round((*trend.Last-*trend.First) / *trend.First * 10000) / 100
+		trend.ChangePercent = &change
+	}
+	return trend
+}
+
+func floatPtr(v sql.NullFloat64) *float64 {
+	if !v.Valid {
+		return nil
+	}
+	return &v.Float64
+}
@@ -0,0 +1,193 @@
+package cards
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/lib/pq"
+	"github.com/quehorrifico/mana-tomb/backend/db"
+	"github.com/quehorrifico/mana-tomb/backend/middleware"
+	"github.com/quehorrifico/mana-tomb/backend/models"
+)
+
+// PrintingColumns are every stored column of printings, in the order ScanPrinting reads them.
+const PrintingColumns = `id, COALESCE(oracle_id::text, ''), multiverse_ids, COALESCE(mtgo_id, 0), COALESCE(arena_id, 0),
+	COALESCE(tcgplayer_id, 0), COALESCE(cardmarket_id, 0), name, COALESCE(lang, ''), COALESCE(printed_name, ''),
+	COALESCE(printed_type_line, ''), COALESCE(printed_text, ''), COALESCE(to_char(released_at, 'YYYY-MM-DD'), ''),
+	COALESCE(scryfall_uri, ''), COALESCE(layout, ''), image_uris, COALESCE(mana_cost, ''), COALESCE(type_line, ''), games,
+	COALESCE(foil, FALSE), COALESCE(nonfoil, FALSE), finishes, COALESCE(oversized, FALSE), COALESCE(promo, FALSE), promo_types,
+	COALESCE(reprint, FALSE), COALESCE(variation, FALSE), COALESCE(variation_of::text, ''), COALESCE(set_id::text, ''), set,
+	COALESCE(set_name, ''), COALESCE(set_type, ''), COALESCE(collector_number, ''), COALESCE(digital, FALSE), COALESCE(rarity, ''),
+	COALESCE(artist, ''), COALESCE(illustration_id::text, ''), COALESCE(border_color, ''), COALESCE(frame, ''), frame_effects,
+	COALESCE(full_art, FALSE), COALESCE(textless, FALSE), COALESCE(booster, FALSE), prices, card_faces`
+
+// printingFinishes are the finishes a printing can come in, for ?finish=.
+var printingFinishes = map[string]bool{"nonfoil": true, "foil": true, "etched": true}
+
+// ScanPrinting reads a row of PrintingColumns into the printing returned by the API: its JSONB
+// columns decoded and its mana parsed.
+func ScanPrinting(row db.RowScanner) (PrintingResponse, error) {
+	var p models.Printing
+	var multiverseIDs pq.Int64Array
+	var imageURIsJSON, pricesJSON, cardFacesJSON []byte
+	err := row.Scan(
+		&p.ID, &p.OracleID, &multiverseIDs, &p.MTGOID, &p.ArenaID,
+		&p.TCGPlayerID, &p.CardMarketID, &p.Name, &p.Lang, &p.PrintedName,
+		&p.PrintedTypeLine, &p.PrintedText, &p.ReleasedAt,
+		&p.ScryfallURI, &p.Layout, &imageURIsJSON, &p.ManaCost, &p.TypeLine, pq.Array(&p.Games),
+		&p.Foil, &p.NonFoil, pq.Array(&p.Finishes), &p.Oversized, &p.Promo, pq.Array(&p.PromoTypes),
+		&p.Reprint, &p.Variation, &p.VariationOf, &p.SetID, &p.Set,
+		&p.SetName, &p.SetType, &p.CollectorNumber, &p.Digital, &p.Rarity,
+		&p.Artist, &p.IllustrationID, &p.BorderColor, &p.Frame, pq.Array(&p.FrameEffects),
+		&p.FullArt, &p.Textless, &p.Booster, &pricesJSON, &cardFacesJSON,
+	)
+	if err != nil {
+		return PrintingResponse{}, err
+	}
+
+	for _, id := range multiverseIDs {
+		p.MultiverseIDs = append(p.MultiverseIDs, int(id))
+	}
+	for _, col := range []struct {
+		raw  []byte
+		dest any
+	}{{imageURIsJSON, &p.ImageURIs}, {pricesJSON, &p.Prices}, {cardFacesJSON, &p.CardFaces}} {
+		if len(col.raw) == 0 {
+			continue
+		}
+		if err := json.Unmarshal(col.raw, col.dest); err != nil {
+			return PrintingResponse{}, fmt.Errorf("error decoding printing %s: %w", p.ID, err)
+		}
+	}
+	return NewPrintingResponse(p), nil
+}
+
+// List every printing of a card, newest first, so a deck can name the exact version owned, e.g.
+// /cards/oracle/{oracle_id}/printings?finish=foil&digital=false&lang=en&page=2&page_size=60
+// finish keeps printings available in that finish (nonfoil, foil or etched), and digital=false
+// paper printings only.
+func GetCardPrintings(w http.ResponseWriter, r *http.Request) {
+	middleware.EnableCORS(w)
+	if r.Method == "OPTIONS" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	oracleID := r.PathValue("oracle_id")
+	if !db.UUIDPattern.MatchString(oracleID) {
+		http.Error(w, "Oracle ID must be a UUID", http.StatusBadRequest)
+		return
+	}
+
+	query := r.URL.Query()
+	conditions := []string{"oracle_id = $1", "retired_at IS NULL"}
+	args := []interface{}{oracleID}
+	addCondition := func(format string, value interface{}) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(format, len(args)))
+	}
+
+	if v := query.Get("finish"); v != "" {
+		if !printingFinishes[v] {
+			http.Error(w, "Invalid 'finish', expected nonfoil, foil or etched", http.StatusBadRequest)
+			return
+		}
+		addCondition("$%d = ANY(finishes)", v)
+	}
+	if v := query.Get("digital"); v != "" {
+		digital, err := strconv.ParseBool(v)
+		if err != nil {
+			http.Error(w, "Invalid 'digital', expected true or false", http.StatusBadRequest)
+			return
+		}
+		addCondition("COALESCE(digital, FALSE) = $%d", digital)
+	}
+	if v := query.Get("lang"); v != "" {
+		addCondition("lang = $%d", v)
+	}
+
+	page, pageSize := 1, defaultSearchPageSize
+	for _, param := range []struct {
+		name string
+		dest *int
+		max  int
+	}{{"page", &page, 0}, {"page_size", &pageSize, maxSearchPageSize}} {
+		v := query.Get(param.name)
+		if v == "" {
+			continue
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || (param.max > 0 && n > param.max) {
+			http.Error(w, fmt.Sprintf("Invalid '%s'", param.name), http.StatusBadRequest)
+			return
+		}
+		*param.dest = n
+	}
+
+	// The card itself must exist, even if no printing passes the filters
+	var exists bool
+	err := DB.QueryRow(`SELECT EXISTS (SELECT 1 FROM oracle_cards WHERE oracle_id = $1 AND retired_at IS NULL)`, oracleID).Scan(&exists)
+	if err != nil {
+		http.Error(w, "Error fetching printings", http.StatusInternalServerError)
+		log.Println("❌ Error fetching card:", err)
+		return
+	}
+	if !exists {
+		http.Error(w, "Card not found", http.StatusNotFound)
+		return
+	}
+
+	where := strings.Join(conditions, " AND ")
+	var total int
+	if err := DB.QueryRow(`SELECT count(*) FROM printings WHERE `+where, args...).Scan(&total); err != nil {
+		http.Error(w, "Error fetching printings", http.StatusInternalServerError)
+		log.Println("❌ Error counting printings:", err)
+		return
+	}
+
+	args = append(args, pageSize, (page-1)*pageSize)
+	rows, err := DB.Query(`
+		SELECT `+PrintingColumns+`
+		FROM printings
+		WHERE `+where+`
+		ORDER BY released_at DESC NULLS LAST, set, NULLIF(substring(collector_number FROM '^[0-9]+'), '')::int NULLS LAST,
+			collector_number, lang, id
+		LIMIT $`+strconv.Itoa(len(args)-1)+` OFFSET $`+strconv.Itoa(len(args))+`;
+	`, args...)
+	if err != nil {
+		http.Error(w, "Error fetching printings", http.StatusInternalServerError)
+		log.Println("❌ Error fetching printings:", err)
+		return
+	}
+	defer rows.Close()
+
+	printings := []PrintingResponse{}
+	for rows.Next() {
+		p, err := ScanPrinting(rows)
+		if err != nil {
+			http.Error(w, "Error fetching printings", http.StatusInternalServerError)
+			log.Println("❌ Error scanning printing:", err)
+			return
+		}
+		printings = append(printings, p)
+	}
+	if err := rows.Err(); err != nil {
+		http.Error(w, "Error fetching printings", http.StatusInternalServerError)
+		log.Println("❌ Error reading printings:", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"oracle_id": oracleID,
+		"page":      page,
+		"page_size": pageSize,
+		"total":     total,
+		"has_more":  page*pageSize < total,
+		"data":      printings,
+	})
+}
@@ -0,0 +1,81 @@
+package cards
+
+import (
+	"github.com/quehorrifico/mana-tomb/backend/mana"
+	"github.com/quehorrifico/mana-tomb/backend/models"
+)
+
+// cardResponse is an oracle card as the API returns it. Handlers encode it rather than
+// models.OracleCard, so the fields only responses carry stay out of the stored model.
+type cardResponse struct {
+	models.OracleCard
+	CardFaces []CardFaceResponse `json:"card_faces,omitempty"`
+
+	// Parsed forms of ManaCost and the symbols in OracleText
+	ParsedManaCost    *mana.Cost    `json:"parsed_mana_cost,omitempty"`
+	OracleTextSymbols []mana.Symbol `json:"oracle_text_symbols,omitempty"`
+
+	// Oracle text around the matches of a full-text search, matches wrapped in <mark> tags
+	Snippet string `json:"snippet,omitempty"`
+}
+
+// newCardResponse builds the response for a stored card, parsing the mana of the card and its faces.
+func newCardResponse(card models.OracleCard) cardResponse {
+	return cardResponse{
+		OracleCard:        card,
+		CardFaces:         newCardFaceResponses(card.CardFaces),
+		ParsedManaCost:    mana.ParsedCost(card.ManaCost),
+		OracleTextSymbols: nonEmptySymbols(mana.ParseText(card.OracleText)),
+	}
+}
+
+// PrintingResponse is a printing as the API returns it, here and in the set card lists.
+type PrintingResponse struct {
+	models.Printing
+	CardFaces []CardFaceResponse `json:"card_faces,omitempty"`
+
+	// Parsed form of ManaCost
+	ParsedManaCost *mana.Cost `json:"parsed_mana_cost,omitempty"`
+}
+
+// NewPrintingResponse builds the response for a stored printing, parsing the mana of the printing
+// and its faces.
+func NewPrintingResponse(p models.Printing) PrintingResponse {
+	return PrintingResponse{
+		Printing:       p,
+		CardFaces:      newCardFaceResponses(p.CardFaces),
+		ParsedManaCost: mana.ParsedCost(p.ManaCost),
+	}
+}
+
+// CardFaceResponse is one face of a card or printing as the API returns it.
+type CardFaceResponse struct {
+	models.CardFace
+	ParsedManaCost    *mana.Cost    `json:"parsed_mana_cost,omitempty"`
+	OracleTextSymbols []mana.Symbol `json:"oracle_text_symbols,omitempty"`
+}
+
+// newCardFaceResponses builds the responses for faces, nil for a single-faced card so the field is
+// left out.
+func newCardFaceResponses(faces []models.CardFace) []CardFaceResponse {
+	if len(faces) == 0 {
+		return nil
+	}
+	responses := make([]CardFaceResponse, len(faces))
+	for i, face := range faces {
+		responses[i] = CardFaceResponse{
+			CardFace:          face,
+			ParsedManaCost:    mana.ParsedCost(face.ManaCost),
+			OracleTextSymbols: nonEmptySymbols(mana.ParseText(face.OracleText)),
+		}
+	}
+	return responses
+}
+
+// nonEmptySymbols returns nil for no symbols, so the field is left out of responses.
+func nonEmptySymbols(symbols []mana.Symbol) []mana.Symbol {
+	if len(symbols) == 0 {
+		return nil
+	}
+	return symbols
+}
@@ -0,0 +1,90 @@
+package cards
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/quehorrifico/mana-tomb/backend/models"
+)
+
+// encode returns v encoded as a JSON object, decoded back into a map.
+func encode(t *testing.T, v any) map[string]any {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fields map[string]any
+	if err := json.Unmarshal(data, &fields); err != nil {
+		t.Fatal(err)
+	}
+	return fields
+}
+
+func TestCardResponseParsesMana(t *testing.T) {
+	fields := encode(t, newCardResponse(models.OracleCard{
+		Name:       "Birds of Paradise",
+		ManaCost:   "{G}",
+		OracleText: "Flying\n{T}: Add one mana of any color.",
+	}))
+	cost, ok := fields["parsed_mana_cost"].(map[string]any)
+	if !ok || cost["mana_value"] != 1.0 {
+		t.Errorf("parsed_mana_cost = %v, want a cost of mana value 1", fields["parsed_mana_cost"])
+	}
+	if symbols, _ := fields["oracle_text_symbols"].([]any); len(symbols) != 1 {
+		t.Errorf("oracle_text_symbols = %v, want the {T} symbol", fields["oracle_text_symbols"])
+	}
+	if _, ok := fields["card_faces"]; ok {
+		t.Errorf("card_faces of a single-faced card = %v, want it left out", fields["card_faces"])
+	}
+	if fields["name"] != "Birds of Paradise" || fields["mana_cost"] != "{G}" {
+		t.Errorf("stored fields missing from the response: %v", fields)
+	}
+}
+
+func TestCardResponseLeavesOutEmptyMana(t *testing.T) {
+	fields := encode(t, newCardResponse(models.OracleCard{Name: "Forest", TypeLine: "Basic Land — Forest"}))
+	for _, name := range []string{"parsed_mana_cost", "oracle_text_symbols", "card_faces"} {
+		if _, ok := fields[name]; ok {
+			t.Errorf("%s = %v, want it left out", name, fields[name])
+		}
+	}
+}
+
+func TestCardFacesParseMana(t *testing.T) {
+	faces := []models.CardFace{
+		{Name: "Fire", ManaCost: "{1}{R}", OracleText: "Fire deals 2 damage divided as you choose."},
+		{Name: "Ice", ManaCost: "{1}{U}", OracleText: "Tap target permanent.\nDraw a card."},
+	}
+	for _, v := range []any{
+		newCardResponse(models.OracleCard{Name: "Fire // Ice", ManaCost: "{1}{R} // {1}{U}", CardFaces: faces}),
+		NewPrintingResponse(models.Printing{Name: "Fire // Ice", ManaCost: "{1}{R} // {1}{U}", CardFaces: faces}),
+	} {
+		fields := encode(t, v)
+		if cost, _ := fields["parsed_mana_cost"].(map[string]any); cost["mana_value"] != 4.0 {
+			t.Errorf("parsed_mana_cost = %v, want mana value 4", fields["parsed_mana_cost"])
+		}
+		got, _ := fields["card_faces"].([]any)
+		if len(got) != len(faces) {
+			t.Fatalf("card_faces = %v, want %d faces", fields["card_faces"], len(faces))
+		}
+		for i, f := range got {
+			face := f.(map[string]any)
+			cost, _ := face["parsed_mana_cost"].(map[string]any)
+			if face["name"] != faces[i].Name || cost["mana_value"] != 2.0 {
+				t.Errorf("card_faces[%d] = %v, want %s with a parsed cost of mana value 2", i, face, faces[i].Name)
+			}
+		}
+	}
+}
+
+func TestCardResponseSnippet(t *testing.T) {
+	card := newCardResponse(models.OracleCard{Name: "Opt", OracleText: "Scry 1.\nDraw a card."})
+	if _, ok := encode(t, card)["snippet"]; ok {
+		t.Error("snippet of a card outside a full-text search is not left out")
+	}
+	card.Snippet = "Scry 1.\n<mark>Draw</mark> a card."
+	if got := encode(t, card)["snippet"]; got != card.Snippet {
+		t.Errorf("snippet = %v, want %q", got, card.Snippet)
+	}
+}
@@ -0,0 +1,78 @@
+package cards
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/quehorrifico/mana-tomb/backend/db"
+	"github.com/quehorrifico/mana-tomb/backend/middleware"
+	"github.com/quehorrifico/mana-tomb/backend/models"
+)
+
+// Get the official rulings of a card by its Scryfall ID or oracle ID
+func GetCardRulings(w http.ResponseWriter, r *http.Request) {
+	middleware.EnableCORS(w)
+	if r.Method == "OPTIONS" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	id := r.PathValue("id")
+	if !db.UUIDPattern.MatchString(id) {
+		http.Error(w, "Card ID must be a Scryfall ID or oracle ID", http.StatusBadRequest)
+		return
+	}
+
+	// Rulings are stored per oracle ID; resolve a printing's Scryfall ID to it first
+	var oracleID string
+	err := DB.QueryRow(`
+		SELECT COALESCE(
+			(SELECT oracle_id FROM oracle_cards WHERE id = $1),
+			(SELECT oracle_id FROM printings WHERE id = $1),
+			$1::uuid
+		)::text;
+	`, id).Scan(&oracleID)
+	if err != nil {
+		http.Error(w, "Error fetching rulings", http.StatusInternalServerError)
+		log.Println("❌ Error resolving oracle ID:", err)
+		return
+	}
+
+	rulings, err := loadRulings(oracleID)
+	if err != nil {
+		http.Error(w, "Error fetching rulings", http.StatusInternalServerError)
+		log.Println("❌ Error fetching rulings:", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"oracle_id": oracleID,
+		"rulings":   rulings,
+	})
+}
+
+// loadRulings returns the rulings for oracleID, oldest first, as Scryfall lists them.
+func loadRulings(oracleID string) ([]models.Ruling, error) {
+	rows, err := DB.Query(`
+		SELECT oracle_id, source, to_char(published_at, 'YYYY-MM-DD'), comment
+		FROM rulings
+		WHERE oracle_id = $1
+		ORDER BY published_at, id;
+	`, oracleID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rulings := []models.Ruling{}
+	for rows.Next() {
+		var ruling models.Ruling
+		if err := rows.Scan(&ruling.OracleID, &ruling.Source, &ruling.PublishedAt, &ruling.Comment); err != nil {
+			return nil, err
+		}
+		rulings = append(rulings, ruling)
+	}
+	return rulings, rows.Err()
+}
@@ -0,0 +1,233 @@
+package cards
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/quehorrifico/mana-tomb/backend/middleware"
+	"github.com/quehorrifico/mana-tomb/backend/search"
+)
+
+// Page sizes of search results. Fuzzy name matches keep their old page of 10.
+const (
+	defaultSearchPageSize = 60
+	fuzzyMatchPageSize    = 10
+	maxSearchPageSize     = 175
+)
+
+// pageParams are the paging parameters of a search request: ?order=&dir=&page_size=&cursor=
+type pageParams struct {
+	sort   search.Sort
+	size   int
+	cursor *search.Cursor
+	key    string // fingerprint of the query and order, stored in cursors
+}
+
+// cardQuery selects the live oracle cards matching where. from adds tables to the FROM list, and
+// snippet, if set, is selected into each card's Snippet.
+type cardQuery struct {
+	where   string
+	args    []interface{}
+	from    string
+	snippet string
+}
+
+// cardPage is one page of search results.
+type cardPage struct {
+	cards      []cardResponse
+	total      int
+	hasMore    bool
+	nextCursor string
+}
+
+// Search cards with Scryfall-style syntax, e.g.
+// /cards/search?q=t:creature c:g cmc<=3 o:"draw a card" f:commander -t:legendary&order=usd
+// With mode=text, words and phrases are matched by full-text search over name, type line and
+// rules text instead and ranked by relevance, e.g. /cards/search?mode=text&q="draw a card" t:instant
+// Results come a page at a time; pass next_cursor back as ?cursor= for the next page.
+func SearchCards(w http.ResponseWriter, r *http.Request) {
+	middleware.EnableCORS(w)
+	if r.Method == "OPTIONS" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	q, mode := r.URL.Query().Get("q"), r.URL.Query().Get("mode")
+	if mode != "" && mode != "text" {
+		http.Error(w, "mode must be text or left out", http.StatusBadRequest)
+		return
+	}
+	node, err := search.Parse(q)
+	if err != nil {
+		writeQueryError(w, q, err)
+		return
+	}
+
+	var query cardQuery
+	defaultOrder := "name"
+	if mode == "text" {
+		var ft search.FullText
+		ft, query.args, err = search.CompileFullText(node, nil)
+		query.where = "search_vector @@ tsq.q AND " + ft.Filter
+		query.from = "(SELECT " + ft.TSQuery + " AS q) tsq CROSS JOIN LATERAL (SELECT ts_rank(search_vector, tsq.q) AS rank) r"
+		query.snippet = search.Snippet("tsq.q")
+		defaultOrder = "relevance"
+	} else {
+		query.where, query.args, err = search.Compile(node, nil)
+	}
+	if err != nil {
+		writeQueryError(w, q, err)
+		return
+	}
+
+	params, err := parsePageParams(r, mode+":"+node.String(), defaultSearchPageSize, defaultOrder)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	page, err := queryCardPage(query, params)
+	if err != nil {
+		http.Error(w, "Error searching cards", http.StatusInternalServerError)
+		log.Println("❌ Error searching cards:", err)
+		return
+	}
+
+	response := map[string]interface{}{
+		"query":       node.String(),
+		"order":       params.sort.Field,
+		"dir":         params.sort.Dir(),
+		"total_cards": page.total,
+		"page_size":   params.size,
+		"has_more":    page.hasMore,
+		"next_cursor": page.nextCursor,
+		"data":        page.cards,
+	}
+	if mode != "" {
+		response["mode"] = mode
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// parsePageParams reads the order (defaultOrder if not given), direction, page size (defaultSize
+// if not given) and cursor of a search for the query identified by queryKey. Only searches ordered
+// by relevance by default can be ordered by relevance.
+func parsePageParams(r *http.Request, queryKey string, defaultSize int, defaultOrder string) (pageParams, error) {
+	order := r.URL.Query().Get("order")
+	if order == "" {
+		order = defaultOrder
+	}
+	if order == "relevance" && defaultOrder != "relevance" {
+		return pageParams{}, errors.New("order=relevance needs mode=text")
+	}
+	sort, err := search.ParseSort(order, r.URL.Query().Get("dir"))
+	if err != nil {
+		return pageParams{}, err
+	}
+	params := pageParams{sort: sort, size: defaultSize, key: search.Fingerprint(queryKey, sort)}
+
+	if v := r.URL.Query().Get("page_size"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxSearchPageSize {
+			return pageParams{}, fmt.Errorf("page_size must be between 1 and %d", maxSearchPageSize)
+		}
+		params.size = n
+	}
+	if v := r.URL.Query().Get("cursor"); v != "" {
+		cursor, err := search.DecodeCursor(v, params.key)
+		if err != nil {
+			return pageParams{}, fmt.Errorf("invalid cursor: %w", err)
+		}
+		params.cursor = cursor
+	}
+	return params, nil
+}
+
+// queryCardPage returns the page of cards selected by q that params asks for, with the total
+// number of matching cards.
+func queryCardPage(q cardQuery, params pageParams) (cardPage, error) {
+	from, where, args := "oracle_cards", q.where, q.args
+	if q.from != "" {
+		from += " CROSS JOIN " + q.from
+	}
+	snippet := "''"
+	if q.snippet != "" {
+		snippet = q.snippet
+	}
+
+	var page cardPage
+	if err := DB.QueryRow(`SELECT count(*) FROM `+from+` WHERE retired_at IS NULL AND `+where, args...).Scan(&page.total); err != nil {
+		return page, fmt.Errorf("error counting cards: %w", err)
+	}
+
+	if params.cursor != nil {
+		var after string
+		after, args = params.sort.After(params.cursor, args)
+		where = where + " AND " + after
+	}
+	rows, err := DB.Query(`
+		SELECT `+cardColumns+`, (`+params.sort.Expr()+`)::text, COALESCE(`+snippet+`, '')
+		FROM `+from+`
+		WHERE retired_at IS NULL AND `+where+`
+		ORDER BY `+params.sort.OrderBy()+`
+		LIMIT `+strconv.Itoa(params.size+1)+`;
+	`, args...)
+	if err != nil {
+		return page, fmt.Errorf("error fetching cards: %w", err)
+	}
+	defer rows.Close()
+
+	page.cards = []cardResponse{}
+	var last search.Cursor
+	for rows.Next() {
+		var sortValue sql.NullString
+		var snippet string
+		card, err := scanCard(rows, &sortValue, &snippet)
+		if err != nil {
+			return page, fmt.Errorf("error scanning card: %w", err)
+		}
+		if len(page.cards) == params.size {
+			page.hasMore = true
+			break
+		}
+		card.Snippet = snippet
+		page.cards = append(page.cards, card)
+
+		last = search.Cursor{Name: card.Name, ID: card.ID, Key: params.key}
+		if sortValue.Valid {
+			last.Value = &sortValue.String
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return page, fmt.Errorf("error reading cards: %w", err)
+	}
+
+	if page.hasMore {
+		page.nextCursor = last.Encode()
+	}
+	return page, nil
+}
+
+// writeQueryError answers a query that does not parse or compile with 400 and the error's
+// message and position in the query.
+func writeQueryError(w http.ResponseWriter, q string, err error) {
+	var queryErr *search.Error
+	if !errors.As(err, &queryErr) {
+		http.Error(w, "Error searching cards", http.StatusInternalServerError)
+		log.Println("❌ Error compiling search query:", err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"error":   "invalid_query",
+		"query":   q,
+		"details": queryErr,
+	})
+}
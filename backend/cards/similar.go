@@ -0,0 +1,84 @@
+package cards
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+)
+
+// defaultSimilarityThreshold is the smallest trigram similarity, from 0 to 1, at which a card name
+// is suggested for a misspelled one, unless NAME_SIMILARITY_THRESHOLD or ?threshold= set another.
+const defaultSimilarityThreshold = 0.3
+
+// faceNamesExpr is the names of a card's faces as indexed by oracle_cards_face_names_trgm_idx; the
+// expression must stay identical to the index's for the index to be used.
+const faceNamesExpr = `(jsonb_path_query_array(card_faces, '$[*].name')::text)`
+
+// nameSuggestion is a card name similar to one that was looked up.
+type nameSuggestion struct {
+	Name       string  `json:"name"`
+	Similarity float64 `json:"similarity"`
+}
+
+// similarityThreshold returns the ?threshold= of r, else NAME_SIMILARITY_THRESHOLD, else the default.
+func similarityThreshold(r *http.Request) (float64, error) {
+	v := r.URL.Query().Get("threshold")
+	if v == "" {
+		v = os.Getenv("NAME_SIMILARITY_THRESHOLD")
+	}
+	if v == "" {
+		return defaultSimilarityThreshold, nil
+	}
+	threshold, err := strconv.ParseFloat(v, 64)
+	if err != nil || threshold <= 0 || threshold > 1 {
+		return 0, fmt.Errorf("similarity threshold must be a number above 0 and at most 1, got %q", v)
+	}
+	return threshold, nil
+}
+
+// similarCards returns up to limit live cards whose name, or the name of one of whose faces, is at
+// least threshold similar to name, most similar first.
+func similarCards(name string, threshold float64, limit int) ([]cardResponse, []nameSuggestion, error) {
+	// The % and <% operators can use the trigram indexes, but take their threshold from settings,
+	// which SET LOCAL scopes to this transaction
+	tx, err := DB.Begin()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer tx.Rollback()
+
+	t := strconv.FormatFloat(threshold, 'f', -1, 64)
+	if _, err := tx.Exec(`SELECT set_config('pg_trgm.similarity_threshold', $1, true), set_config('pg_trgm.word_similarity_threshold', $1, true)`, t); err != nil {
+		return nil, nil, fmt.Errorf("error setting similarity threshold: %w", err)
+	}
+
+	rows, err := tx.Query(`
+		SELECT `+cardColumns+`,
+			GREATEST(similarity(name, $1), word_similarity($1, `+faceNamesExpr+`)) AS score
+		FROM oracle_cards
+		WHERE retired_at IS NULL AND (name % $1 OR $1 <% `+faceNamesExpr+`)
+		ORDER BY score DESC, name, id
+		LIMIT $2;
+	`, name, limit)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error fetching similar cards: %w", err)
+	}
+	defer rows.Close()
+
+	var cards []cardResponse
+	suggestions := []nameSuggestion{}
+	for rows.Next() {
+		var score float64
+		card, err := scanCard(rows, &score)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error scanning similar card: %w", err)
+		}
+		cards = append(cards, card)
+		suggestions = append(suggestions, nameSuggestion{Name: card.Name, Similarity: score})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("error reading similar cards: %w", err)
+	}
+	return cards, suggestions, nil
+}
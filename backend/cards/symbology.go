@@ -0,0 +1,54 @@
+package cards
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/lib/pq"
+	"github.com/quehorrifico/mana-tomb/backend/middleware"
+	"github.com/quehorrifico/mana-tomb/backend/models"
+)
+
+// List every card symbol with its SVG and English description, for rendering parsed mana costs
+func GetSymbology(w http.ResponseWriter, r *http.Request) {
+	middleware.EnableCORS(w)
+	if r.Method == "OPTIONS" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	rows, err := DB.Query(`
+		SELECT symbol, COALESCE(svg_uri, ''), COALESCE(loose_variant, ''), english, transposable, represents_mana,
+			appears_in_mana_costs, mana_value, hybrid, phyrexian, funny, colors, gatherer_alternates
+		FROM card_symbols
+		ORDER BY symbol;
+	`)
+	if err != nil {
+		http.Error(w, "Error fetching symbology", http.StatusInternalServerError)
+		log.Println("❌ Error fetching symbology:", err)
+		return
+	}
+	defer rows.Close()
+
+	symbols := []models.CardSymbol{}
+	for rows.Next() {
+		var s models.CardSymbol
+		err := rows.Scan(&s.Symbol, &s.SVGURI, &s.LooseVariant, &s.English, &s.Transposable, &s.RepresentsMana,
+			&s.AppearsInManaCosts, &s.ManaValue, &s.Hybrid, &s.Phyrexian, &s.Funny, pq.Array(&s.Colors), pq.Array(&s.GathererAlternates))
+		if err != nil {
+			http.Error(w, "Error fetching symbology", http.StatusInternalServerError)
+			log.Println("❌ Error scanning card symbol:", err)
+			return
+		}
+		symbols = append(symbols, s)
+	}
+	if err := rows.Err(); err != nil {
+		http.Error(w, "Error fetching symbology", http.StatusInternalServerError)
+		log.Println("❌ Error reading symbology:", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(symbols)
+}
@@ -0,0 +1,57 @@
+-- Baseline: the tables previously created by utils.Ensure*Table. Every statement is idempotent so
+-- databases created before migrations existed are adopted without changes.
+
+CREATE TABLE IF NOT EXISTS users (
+	id SERIAL PRIMARY KEY,
+	username TEXT UNIQUE NOT NULL,
+	email TEXT UNIQUE NOT NULL,
+	password TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS sessions (
+	id SERIAL PRIMARY KEY,
+	user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	token TEXT UNIQUE NOT NULL,
+	expires_at TIMESTAMPTZ NOT NULL,
+	created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS bulk_data (
+	id UUID PRIMARY KEY,
+	type TEXT,
+	updated_at TIMESTAMPTZ,
+	download_uri TEXT,
+	name TEXT,
+	description TEXT,
+	size BIGINT,
+	content_type TEXT,
+	content_encoding TEXT
+);
+
+CREATE TABLE IF NOT EXISTS oracle_cards (
+	id UUID PRIMARY KEY,
+	name TEXT NOT NULL,
+	mana_cost TEXT,
+	type_line TEXT NOT NULL,
+	oracle_text TEXT,
+	image_uris JSONB NOT NULL,
+	set TEXT NOT NULL,
+	set_name TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS unique_artwork (
+	id UUID PRIMARY KEY,
+	name TEXT,
+	image_uris JSONB,
+	set TEXT,
+	set_name TEXT
+);
+
+CREATE TABLE IF NOT EXISTS proto_commander_decks (
+	id SERIAL PRIMARY KEY,
+	user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	name TEXT NOT NULL,
+	description TEXT,
+	commander TEXT NOT NULL,
+	cards TEXT[]
+);
@@ -0,0 +1,9 @@
+-- bulk_data: the uri column FetchAndParseBulkData upserts, plus the refresh bookkeeping used by
+-- utils.RefreshBulkData.
+ALTER TABLE bulk_data
+	ADD COLUMN IF NOT EXISTS uri TEXT,
+	ADD COLUMN IF NOT EXISTS etag TEXT,
+	ADD COLUMN IF NOT EXISTS last_modified TEXT,
+	ADD COLUMN IF NOT EXISTS ingested_updated_at TIMESTAMPTZ,
+	ADD COLUMN IF NOT EXISTS last_checked_at TIMESTAMPTZ,
+	ADD COLUMN IF NOT EXISTS last_refresh_status TEXT;
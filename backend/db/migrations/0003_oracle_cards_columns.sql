@@ -0,0 +1,60 @@
+-- oracle_cards: every column InsertOracleCards writes. The baseline table only declared 8 of them.
+ALTER TABLE oracle_cards
+	ADD COLUMN IF NOT EXISTS oracle_id UUID,
+	ADD COLUMN IF NOT EXISTS multiverse_ids INTEGER[],
+	ADD COLUMN IF NOT EXISTS mtgo_id INTEGER,
+	ADD COLUMN IF NOT EXISTS mtgo_foil_id INTEGER,
+	ADD COLUMN IF NOT EXISTS tcgplayer_id INTEGER,
+	ADD COLUMN IF NOT EXISTS cardmarket_id INTEGER,
+	ADD COLUMN IF NOT EXISTS name TEXT,
+	ADD COLUMN IF NOT EXISTS lang TEXT,
+	ADD COLUMN IF NOT EXISTS released_at DATE,
+	ADD COLUMN IF NOT EXISTS uri TEXT,
+	ADD COLUMN IF NOT EXISTS scryfall_uri TEXT,
+	ADD COLUMN IF NOT EXISTS layout TEXT,
+	ADD COLUMN IF NOT EXISTS highres_image BOOLEAN,
+	ADD COLUMN IF NOT EXISTS image_status TEXT,
+	ADD COLUMN IF NOT EXISTS image_uris JSONB,
+	ADD COLUMN IF NOT EXISTS mana_cost TEXT,
+	ADD COLUMN IF NOT EXISTS cmc NUMERIC,
+	ADD COLUMN IF NOT EXISTS type_line TEXT,
+	ADD COLUMN IF NOT EXISTS oracle_text TEXT,
+	ADD COLUMN IF NOT EXISTS colors TEXT[],
+	ADD COLUMN IF NOT EXISTS color_identity TEXT[],
+	ADD COLUMN IF NOT EXISTS keywords TEXT[],
+	ADD COLUMN IF NOT EXISTS legalities JSONB,
+	ADD COLUMN IF NOT EXISTS games TEXT[],
+	ADD COLUMN IF NOT EXISTS reserved BOOLEAN,
+	ADD COLUMN IF NOT EXISTS game_changer BOOLEAN,
+	ADD COLUMN IF NOT EXISTS foil BOOLEAN,
+	ADD COLUMN IF NOT EXISTS nonfoil BOOLEAN,
+	ADD COLUMN IF NOT EXISTS finishes TEXT[],
+	ADD COLUMN IF NOT EXISTS oversized BOOLEAN,
+	ADD COLUMN IF NOT EXISTS promo BOOLEAN,
+	ADD COLUMN IF NOT EXISTS reprint BOOLEAN,
+	ADD COLUMN IF NOT EXISTS variation BOOLEAN,
+	ADD COLUMN IF NOT EXISTS set_id UUID,
+	ADD COLUMN IF NOT EXISTS set TEXT,
+	ADD COLUMN IF NOT EXISTS set_name TEXT,
+	ADD COLUMN IF NOT EXISTS set_type TEXT,
+	ADD COLUMN IF NOT EXISTS set_uri TEXT,
+	ADD COLUMN IF NOT EXISTS set_search_uri TEXT,
+	ADD COLUMN IF NOT EXISTS scryfall_set_uri TEXT,
+	ADD COLUMN IF NOT EXISTS rulings_uri TEXT,
+	ADD COLUMN IF NOT EXISTS prints_search_uri TEXT,
+	ADD COLUMN IF NOT EXISTS collector_number TEXT,
+	ADD COLUMN IF NOT EXISTS digital BOOLEAN,
+	ADD COLUMN IF NOT EXISTS rarity TEXT,
+	ADD COLUMN IF NOT EXISTS flavor_text TEXT,
+	ADD COLUMN IF NOT EXISTS card_back_id UUID,
+	ADD COLUMN IF NOT EXISTS artist TEXT,
+	ADD COLUMN IF NOT EXISTS artist_ids TEXT[],
+	ADD COLUMN IF NOT EXISTS illustration_id UUID,
+	ADD COLUMN IF NOT EXISTS border_color TEXT,
+	ADD COLUMN IF NOT EXISTS frame TEXT,
+	ADD COLUMN IF NOT EXISTS full_art BOOLEAN,
+	ADD COLUMN IF NOT EXISTS textless BOOLEAN,
+	ADD COLUMN IF NOT EXISTS booster BOOLEAN,
+	ADD COLUMN IF NOT EXISTS story_spotlight BOOLEAN,
+	ADD COLUMN IF NOT EXISTS edhrec_rank INTEGER,
+	ADD COLUMN IF NOT EXISTS prices JSONB;
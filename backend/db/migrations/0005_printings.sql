@@ -0,0 +1,48 @@
+-- printings: every printing of every card from the default_cards (and all_cards) bulk files,
+-- linked to its oracle card through oracle_id.
+CREATE TABLE IF NOT EXISTS printings (
+	id UUID PRIMARY KEY,
+	oracle_id UUID,
+	multiverse_ids INTEGER[],
+	mtgo_id INTEGER,
+	arena_id INTEGER,
+	tcgplayer_id INTEGER,
+	cardmarket_id INTEGER,
+	name TEXT NOT NULL,
+	lang TEXT,
+	released_at DATE,
+	scryfall_uri TEXT,
+	layout TEXT,
+	image_uris JSONB,
+	mana_cost TEXT,
+	type_line TEXT,
+	games TEXT[],
+	foil BOOLEAN,
+	nonfoil BOOLEAN,
+	finishes TEXT[],
+	oversized BOOLEAN,
+	promo BOOLEAN,
+	promo_types TEXT[],
+	reprint BOOLEAN,
+	variation BOOLEAN,
+	variation_of UUID,
+	set_id UUID,
+	set TEXT,
+	set_name TEXT,
+	set_type TEXT,
+	collector_number TEXT,
+	digital BOOLEAN,
+	rarity TEXT,
+	artist TEXT,
+	illustration_id UUID,
+	border_color TEXT,
+	frame TEXT,
+	frame_effects TEXT[],
+	full_art BOOLEAN,
+	textless BOOLEAN,
+	booster BOOLEAN,
+	prices JSONB
+);
+
+CREATE INDEX IF NOT EXISTS printings_oracle_id_idx ON printings (oracle_id);
+CREATE INDEX IF NOT EXISTS printings_set_collector_number_idx ON printings (set, collector_number);
@@ -0,0 +1,5 @@
+-- card_faces: per-face name, mana cost, type line, oracle text and image URIs of multi-faced cards.
+-- Single-faced cards store an empty array.
+ALTER TABLE oracle_cards ADD COLUMN IF NOT EXISTS card_faces JSONB NOT NULL DEFAULT '[]';
+ALTER TABLE unique_artwork ADD COLUMN IF NOT EXISTS card_faces JSONB NOT NULL DEFAULT '[]';
+ALTER TABLE printings ADD COLUMN IF NOT EXISTS card_faces JSONB NOT NULL DEFAULT '[]';
@@ -0,0 +1,11 @@
+-- rulings: official rulings from the rulings bulk file. Rulings have no ID of their own, so a
+-- unique index over their content keeps re-ingestion idempotent.
+CREATE TABLE IF NOT EXISTS rulings (
+	id BIGSERIAL PRIMARY KEY,
+	oracle_id UUID NOT NULL,
+	source TEXT NOT NULL,
+	published_at DATE NOT NULL,
+	comment TEXT NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS rulings_content_idx ON rulings (oracle_id, source, published_at, md5(comment));
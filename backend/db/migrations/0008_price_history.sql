@@ -0,0 +1,13 @@
+-- price_history: one price snapshot per printing per day, appended by every default_cards/all_cards
+-- ingestion. A second run on the same day overwrites that day's snapshot.
+CREATE TABLE IF NOT EXISTS price_history (
+	printing_id UUID NOT NULL,
+	snapshot_date DATE NOT NULL,
+	usd NUMERIC(12, 2),
+	usd_foil NUMERIC(12, 2),
+	usd_etched NUMERIC(12, 2),
+	eur NUMERIC(12, 2),
+	eur_foil NUMERIC(12, 2),
+	tix NUMERIC(12, 2),
+	PRIMARY KEY (printing_id, snapshot_date)
+);
@@ -0,0 +1,30 @@
+-- Card tables are upserted in place: last_seen_at is stamped with the start of the ingestion run
+-- that last contained the card, and cards missing from a complete run get retired_at instead of
+-- being deleted, so decks referencing them keep working.
+ALTER TABLE oracle_cards
+	ADD COLUMN IF NOT EXISTS last_seen_at TIMESTAMPTZ,
+	ADD COLUMN IF NOT EXISTS retired_at TIMESTAMPTZ;
+
+ALTER TABLE unique_artwork
+	ADD COLUMN IF NOT EXISTS last_seen_at TIMESTAMPTZ,
+	ADD COLUMN IF NOT EXISTS retired_at TIMESTAMPTZ;
+
+ALTER TABLE printings
+	ADD COLUMN IF NOT EXISTS last_seen_at TIMESTAMPTZ,
+	ADD COLUMN IF NOT EXISTS retired_at TIMESTAMPTZ;
+
+-- card_changelog: one row per card added, changed or retired by an ingestion run. fields lists the
+-- changed columns of "changed" entries.
+CREATE TABLE IF NOT EXISTS card_changelog (
+	id BIGSERIAL PRIMARY KEY,
+	run_started_at TIMESTAMPTZ NOT NULL,
+	table_name TEXT NOT NULL,
+	card_id UUID NOT NULL,
+	card_name TEXT,
+	change TEXT NOT NULL,
+	fields TEXT[],
+	recorded_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS card_changelog_run_idx ON card_changelog (run_started_at, table_name);
+CREATE INDEX IF NOT EXISTS card_changelog_card_idx ON card_changelog (card_id);
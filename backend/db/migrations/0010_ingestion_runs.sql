@@ -0,0 +1,32 @@
+-- ingestion_runs: one row per attempt to ingest a bulk file, whether downloaded by a refresh or
+-- imported from disk. status is running until the run finishes as succeeded, not_modified or failed.
+CREATE TABLE IF NOT EXISTS ingestion_runs (
+	id BIGSERIAL PRIMARY KEY,
+	bulk_type TEXT NOT NULL,
+	bulk_data_id UUID,
+	source TEXT NOT NULL,
+	trigger TEXT NOT NULL,
+	status TEXT NOT NULL DEFAULT 'running',
+	started_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+	finished_at TIMESTAMPTZ,
+	bytes BIGINT NOT NULL DEFAULT 0,
+	cards_read INTEGER NOT NULL DEFAULT 0,
+	cards_added INTEGER NOT NULL DEFAULT 0,
+	cards_changed INTEGER NOT NULL DEFAULT 0,
+	cards_retired INTEGER NOT NULL DEFAULT 0,
+	cards_rejected INTEGER NOT NULL DEFAULT 0,
+	error TEXT
+);
+
+CREATE INDEX IF NOT EXISTS ingestion_runs_started_at_idx ON ingestion_runs (started_at DESC);
+
+-- ingestion_run_errors: the cards a run rejected and why.
+CREATE TABLE IF NOT EXISTS ingestion_run_errors (
+	id BIGSERIAL PRIMARY KEY,
+	run_id BIGINT NOT NULL REFERENCES ingestion_runs (id) ON DELETE CASCADE,
+	card_id TEXT,
+	card_name TEXT,
+	reason TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS ingestion_run_errors_run_idx ON ingestion_run_errors (run_id);
@@ -0,0 +1,10 @@
+-- scheduled_jobs: the last run of each scheduler job, shared by every backend instance. The
+-- scheduler compares last_started_at with the job's schedule to skip slots another instance
+-- already ran and to catch up on slots missed while no instance was up.
+CREATE TABLE IF NOT EXISTS scheduled_jobs (
+	name TEXT PRIMARY KEY,
+	last_started_at TIMESTAMPTZ NOT NULL,
+	last_finished_at TIMESTAMPTZ,
+	last_status TEXT NOT NULL,
+	last_error TEXT
+);
@@ -0,0 +1,26 @@
+-- sets: Scryfall's set catalog, refreshed with the bulk data. Card tables refer to sets by code.
+CREATE TABLE IF NOT EXISTS sets (
+	id UUID PRIMARY KEY,
+	code TEXT NOT NULL UNIQUE,
+	mtgo_code TEXT,
+	arena_code TEXT,
+	tcgplayer_id INTEGER,
+	name TEXT NOT NULL,
+	set_type TEXT NOT NULL,
+	released_at DATE,
+	block_code TEXT,
+	block TEXT,
+	parent_set_code TEXT,
+	card_count INTEGER NOT NULL DEFAULT 0,
+	printed_size INTEGER,
+	digital BOOLEAN NOT NULL DEFAULT FALSE,
+	foil_only BOOLEAN NOT NULL DEFAULT FALSE,
+	nonfoil_only BOOLEAN NOT NULL DEFAULT FALSE,
+	scryfall_uri TEXT,
+	uri TEXT,
+	icon_svg_uri TEXT,
+	search_uri TEXT
+);
+
+CREATE INDEX IF NOT EXISTS sets_released_at_idx ON sets (released_at);
+CREATE INDEX IF NOT EXISTS sets_parent_set_code_idx ON sets (parent_set_code);
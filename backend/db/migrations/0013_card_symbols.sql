@@ -0,0 +1,17 @@
+-- card_symbols: Scryfall's symbology, every symbol that can appear in mana costs or oracle text,
+-- refreshed with the bulk data.
+CREATE TABLE IF NOT EXISTS card_symbols (
+	symbol TEXT PRIMARY KEY,
+	svg_uri TEXT,
+	loose_variant TEXT,
+	english TEXT NOT NULL,
+	transposable BOOLEAN NOT NULL DEFAULT FALSE,
+	represents_mana BOOLEAN NOT NULL DEFAULT FALSE,
+	appears_in_mana_costs BOOLEAN NOT NULL DEFAULT FALSE,
+	mana_value NUMERIC NOT NULL DEFAULT 0,
+	hybrid BOOLEAN NOT NULL DEFAULT FALSE,
+	phyrexian BOOLEAN NOT NULL DEFAULT FALSE,
+	funny BOOLEAN NOT NULL DEFAULT FALSE,
+	colors TEXT[] NOT NULL DEFAULT '{}',
+	gatherer_alternates TEXT[]
+);
@@ -0,0 +1,13 @@
+-- card_images: which locally cached image file serves each card at each size. Files are stored
+-- under the image cache directory by the SHA-256 of their content, so printings sharing an image
+-- share one file.
+CREATE TABLE IF NOT EXISTS card_images (
+	card_id UUID NOT NULL,
+	size TEXT NOT NULL,
+	source_url TEXT NOT NULL,
+	sha256 TEXT NOT NULL,
+	content_type TEXT NOT NULL,
+	bytes BIGINT NOT NULL,
+	fetched_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+	PRIMARY KEY (card_id, size)
+);
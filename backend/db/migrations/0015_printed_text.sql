@@ -0,0 +1,9 @@
+-- printed_name, printed_type_line, printed_text: the text as printed on non-English printings from
+-- the all_cards bulk file, so cards can be looked up by their foreign names.
+ALTER TABLE printings
+	ADD COLUMN IF NOT EXISTS printed_name TEXT,
+	ADD COLUMN IF NOT EXISTS printed_type_line TEXT,
+	ADD COLUMN IF NOT EXISTS printed_text TEXT;
+
+CREATE INDEX IF NOT EXISTS printings_printed_name_idx ON printings (lower(printed_name)) WHERE printed_name IS NOT NULL;
+CREATE INDEX IF NOT EXISTS printings_oracle_id_lang_idx ON printings (oracle_id, lang);
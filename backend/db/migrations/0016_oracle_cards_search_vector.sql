@@ -0,0 +1,11 @@
+-- search_vector: the words of each oracle card's name (weight A), type line (B) and rules text,
+-- including that of each face (C), for full-text search. Staging tables copy the column without
+-- its expression, and the merge never writes it, so Postgres keeps it current.
+ALTER TABLE oracle_cards ADD COLUMN IF NOT EXISTS search_vector tsvector GENERATED ALWAYS AS (
+	setweight(to_tsvector('english', COALESCE(name, '')), 'A') ||
+	setweight(to_tsvector('english', COALESCE(type_line, '')), 'B') ||
+	setweight(to_tsvector('english', COALESCE(oracle_text, '')), 'C') ||
+	setweight(jsonb_to_tsvector('english', jsonb_path_query_array(COALESCE(card_faces, '[]'), '$[*].oracle_text'), '["string"]'), 'C')
+) STORED;
+
+CREATE INDEX IF NOT EXISTS oracle_cards_search_vector_idx ON oracle_cards USING GIN (search_vector);
@@ -0,0 +1,7 @@
+-- Trigram indexes for typo-tolerant name matching (and for name ILIKE '%...%'). Face names are
+-- indexed as the JSON text of the array of names, which pg_trgm splits into words.
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX IF NOT EXISTS oracle_cards_name_trgm_idx ON oracle_cards USING GIN (name gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS oracle_cards_face_names_trgm_idx
+	ON oracle_cards USING GIN ((jsonb_path_query_array(card_faces, '$[*].name')::text) gin_trgm_ops);
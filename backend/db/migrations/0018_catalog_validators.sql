@@ -0,0 +1,9 @@
+-- catalog_validators: the ETag and Last-Modified of the last /sets and /symbology responses that
+-- were stored, sent back as a conditional request on the next refresh like bulk_data's validators.
+CREATE TABLE IF NOT EXISTS catalog_validators (
+	catalog TEXT PRIMARY KEY,
+	etag TEXT,
+	last_modified TEXT,
+	last_checked_at TIMESTAMPTZ,
+	last_refresh_status TEXT
+);
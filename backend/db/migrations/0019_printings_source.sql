@@ -0,0 +1,7 @@
+-- source: the bulk file (default_cards or all_cards) whose run last loaded the printing. Each run
+-- only retires the printings of its own source, so a default_cards run after all_cards leaves the
+-- foreign printings only all_cards lists alone. Existing rows are attributed by language: only
+-- all_cards carries most non-English printings.
+ALTER TABLE printings ADD COLUMN IF NOT EXISTS source TEXT;
+
+UPDATE printings SET source = CASE WHEN lang = 'en' THEN 'default_cards' ELSE 'all_cards' END WHERE source IS NULL;
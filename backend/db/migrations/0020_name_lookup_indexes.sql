@@ -0,0 +1,7 @@
+-- Indexes for exact, case-insensitive name lookups of many names at once: card names by
+-- lower(name), and the face names of multi-faced cards as a JSON array of lowercased names, which
+-- ?| probes for any of a list of names.
+CREATE INDEX IF NOT EXISTS oracle_cards_lower_name_idx ON oracle_cards (lower(name));
+CREATE INDEX IF NOT EXISTS oracle_cards_lower_face_names_idx
+	ON oracle_cards USING GIN ((jsonb_path_query_array(lower(card_faces::text)::jsonb, '$[*].name')))
+	WHERE jsonb_array_length(card_faces) > 0;
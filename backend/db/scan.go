@@ -0,0 +1,13 @@
+package db
+
+import "regexp"
+
+// RowScanner is satisfied by both *sql.Row and *sql.Rows.
+type RowScanner interface {
+	Scan(dest ...any) error
+}
+
+// UUIDPattern matches the canonical textual form of a UUID, as Scryfall uses for card, oracle and
+// set IDs. Checking an ID against it before casting it to uuid in SQL turns a malformed ID into a
+// clean rejection instead of a query error.
+var UUIDPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
@@ -0,0 +1,255 @@
+// Package imagecache keeps local copies of card images so the API can serve them without going
+// to Scryfall's CDN. Image files are content-addressed: each is stored under the SHA-256 of its
+// bytes, and the card_images table maps a card ID and size to the file.
+package imagecache
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultSizes are the image sizes cached when IMAGE_CACHE_SIZES is not set.
+var DefaultSizes = []string{"small", "normal", "art_crop"}
+
+// KnownSizes are the image sizes Scryfall provides, named as in image_uris.
+var KnownSizes = []string{"small", "normal", "large", "png", "art_crop", "border_crop"}
+
+// maxImageBytes guards against downloading something that is not a card image.
+const maxImageBytes = 20 << 20
+
+// ErrNotCached is returned by Lookup when a card has no cached image of the requested size.
+var ErrNotCached = errors.New("image not cached")
+
+// Cache stores card images in Dir.
+type Cache struct {
+	Dir    string
+	Sizes  []string // sizes that are cached; other sizes are always served from upstream
+	Client *http.Client
+
+	mu       sync.Mutex
+	inflight map[string]bool
+}
+
+// Image is a cached image file.
+type Image struct {
+	Path        string
+	SHA256      string
+	ContentType string
+	FetchedAt   time.Time
+}
+
+// New returns a cache storing the given sizes under dir.
+func New(dir string, sizes []string) *Cache {
+	return &Cache{
+		Dir:      dir,
+		Sizes:    sizes,
+		Client:   &http.Client{Timeout: time.Minute},
+		inflight: make(map[string]bool),
+	}
+}
+
+// NewFromEnv returns a cache rooted at IMAGE_CACHE_DIR (default "image-cache") for the
+// comma-separated sizes in IMAGE_CACHE_SIZES (default small,normal,art_crop).
+func NewFromEnv() (*Cache, error) {
+	dir := os.Getenv("IMAGE_CACHE_DIR")
+	if dir == "" {
+		dir = "image-cache"
+	}
+	sizes := DefaultSizes
+	if v := os.Getenv("IMAGE_CACHE_SIZES"); v != "" {
+		sizes = strings.Split(v, ",")
+		for i, size := range sizes {
+			sizes[i] = strings.TrimSpace(size)
+			if !IsKnownSize(sizes[i]) {
+				return nil, fmt.Errorf("invalid IMAGE_CACHE_SIZES: unknown size %q", sizes[i])
+			}
+		}
+	}
+	return New(dir, sizes), nil
+}
+
+// IsKnownSize reports whether size is one of KnownSizes.
+func IsKnownSize(size string) bool {
+	for _, known := range KnownSizes {
+		if size == known {
+			return true
+		}
+	}
+	return false
+}
+
+// Caches reports whether images of size are kept in the cache.
+func (c *Cache) Caches(size string) bool {
+	for _, s := range c.Sizes {
+		if s == size {
+			return true
+		}
+	}
+	return false
+}
+
+// Lookup returns the cached image of a card at size, or ErrNotCached.
+func (c *Cache) Lookup(ctx context.Context, db *sql.DB, cardID, size string) (Image, error) {
+	var img Image
+	err := db.QueryRowContext(ctx, `
+		SELECT sha256, content_type, fetched_at FROM card_images WHERE card_id = $1 AND size = $2
+	`, cardID, size).Scan(&img.SHA256, &img.ContentType, &img.FetchedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Image{}, ErrNotCached
+	}
+	if err != nil {
+		return Image{}, fmt.Errorf("error looking up cached image: %w", err)
+	}
+
+	img.Path = c.blobPath(img.SHA256)
+	if _, err := os.Stat(img.Path); err != nil {
+		// The index outlived its file, e.g. after the cache directory was cleared
+		return Image{}, ErrNotCached
+	}
+	return img, nil
+}
+
+// Ensure makes sure the image of a card at size from sourceURL is cached, downloading it unless a
+// copy from the same URL already is. It reports whether it downloaded the image.
+func (c *Cache) Ensure(ctx context.Context, db *sql.DB, cardID, size, sourceURL string) (Image, bool, error) {
+	var cachedURL string
+	err := db.QueryRowContext(ctx, `SELECT source_url FROM card_images WHERE card_id = $1 AND size = $2`, cardID, size).Scan(&cachedURL)
+	if err == nil && cachedURL == sourceURL {
+		if img, err := c.Lookup(ctx, db, cardID, size); err == nil {
+			return img, false, nil
+		}
+	}
+	img, err := c.Fetch(ctx, db, cardID, size, sourceURL)
+	return img, err == nil, err
+}
+
+// Fetch downloads the image of a card at size from sourceURL into the cache.
+func (c *Cache) Fetch(ctx context.Context, db *sql.DB, cardID, size, sourceURL string) (Image, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, sourceURL, nil)
+	if err != nil {
+		return Image{}, fmt.Errorf("error building image request: %w", err)
+	}
+	req.Header.Set("User-Agent", "ManaTomb/1.0")
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return Image{}, fmt.Errorf("error fetching %s: %w", sourceURL, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return Image{}, fmt.Errorf("unexpected status fetching %s: %s", sourceURL, resp.Status)
+	}
+	contentType := resp.Header.Get("Content-Type")
+	if !strings.HasPrefix(contentType, "image/") {
+		return Image{}, fmt.Errorf("%s is not an image (%s)", sourceURL, contentType)
+	}
+
+	sum, size64, err := c.store(resp.Body)
+	if err != nil {
+		return Image{}, err
+	}
+
+	img := Image{Path: c.blobPath(sum), SHA256: sum, ContentType: contentType, FetchedAt: time.Now()}
+	_, err = db.ExecContext(ctx, `
+		INSERT INTO card_images (card_id, size, source_url, sha256, content_type, bytes, fetched_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (card_id, size) DO UPDATE
+		SET source_url = EXCLUDED.source_url, sha256 = EXCLUDED.sha256, content_type = EXCLUDED.content_type,
+		    bytes = EXCLUDED.bytes, fetched_at = EXCLUDED.fetched_at;
+	`, cardID, size, sourceURL, sum, contentType, size64, img.FetchedAt)
+	if err != nil {
+		return Image{}, fmt.Errorf("error recording cached image: %w", err)
+	}
+	return img, nil
+}
+
+// EnsureInBackground starts Ensure unless the same image is already being fetched, so that a burst
+// of requests for an uncached image downloads it once.
+func (c *Cache) EnsureInBackground(db *sql.DB, cardID, size, sourceURL string, logErr func(error)) {
+	key := cardID + "/" + size
+	c.mu.Lock()
+	if c.inflight[key] {
+		c.mu.Unlock()
+		return
+	}
+	c.inflight[key] = true
+	c.mu.Unlock()
+
+	go func() {
+		defer func() {
+			c.mu.Lock()
+			delete(c.inflight, key)
+			c.mu.Unlock()
+		}()
+		if _, _, err := c.Ensure(context.Background(), db, cardID, size, sourceURL); err != nil {
+			logErr(err)
+		}
+	}()
+}
+
+// store writes r to a temporary file and moves it to its content address, returning the hex
+// SHA-256 and the size of the content.
+func (c *Cache) store(r io.Reader) (string, int64, error) {
+	tmpDir := filepath.Join(c.Dir, "tmp")
+	if err := os.MkdirAll(tmpDir, 0o755); err != nil {
+		return "", 0, fmt.Errorf("error creating image cache directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(tmpDir, "image-*")
+	if err != nil {
+		return "", 0, fmt.Errorf("error creating image file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	hash := sha256.New()
+	n, err := io.Copy(io.MultiWriter(tmp, hash), io.LimitReader(r, maxImageBytes+1))
+	if err != nil {
+		return "", 0, fmt.Errorf("error downloading image: %w", err)
+	}
+	if n > maxImageBytes {
+		return "", 0, fmt.Errorf("image is larger than %d bytes", maxImageBytes)
+	}
+	if err := tmp.Close(); err != nil {
+		return "", 0, fmt.Errorf("error writing image file: %w", err)
+	}
+
+	sum := hex.EncodeToString(hash.Sum(nil))
+	path := c.blobPath(sum)
+	if _, err := os.Stat(path); err == nil {
+		return sum, n, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return "", 0, fmt.Errorf("error creating image cache directory: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return "", 0, fmt.Errorf("error storing image file: %w", err)
+	}
+	return sum, n, nil
+}
+
+// blobPath fans files out over 256 directories by the first byte of their hash.
+func (c *Cache) blobPath(sum string) string {
+	return filepath.Join(c.Dir, "blobs", sum[:2], sum)
+}
+
+// jsonObject scans a JSONB object of strings, such as image_uris.
+type jsonObject map[string]string
+
+func (o *jsonObject) Scan(src interface{}) error {
+	b, ok := src.([]byte)
+	if !ok {
+		return fmt.Errorf("cannot scan %T into a JSON object", src)
+	}
+	return json.Unmarshal(b, o)
+}
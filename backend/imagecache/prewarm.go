@@ -0,0 +1,88 @@
+package imagecache
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+)
+
+// prewarmInterval spaces out downloads, as Scryfall asks of API clients.
+const prewarmInterval = 100 * time.Millisecond
+
+// PrewarmDeckImages caches every cached size of the image of each card named in a saved deck,
+// commanders included. Decks store card names, which are matched to oracle cards by name or
+// front face name. Images already cached from their current URL are skipped.
+func (c *Cache) PrewarmDeckImages(ctx context.Context, db *sql.DB) error {
+	rows, err := db.QueryContext(ctx, `
+		WITH deck_names AS (
+			SELECT lower(commander) AS name FROM proto_commander_decks
+			UNION
+			SELECT lower(unnest(cards)) FROM proto_commander_decks
+		)
+		SELECT DISTINCT c.id::text, COALESCE(c.image_uris, '{}'), COALESCE(c.card_faces->0->'image_uris', '{}')
+		FROM oracle_cards c
+		JOIN deck_names d ON lower(c.name) = d.name OR lower(c.card_faces->0->>'name') = d.name
+		WHERE c.retired_at IS NULL;
+	`)
+	if err != nil {
+		return fmt.Errorf("error loading deck cards: %w", err)
+	}
+
+	type target struct{ cardID, size, url string }
+	var targets []target
+	for rows.Next() {
+		var cardID string
+		var imageURIs, faceImageURIs jsonObject
+		if err := rows.Scan(&cardID, &imageURIs, &faceImageURIs); err != nil {
+			rows.Close()
+			return fmt.Errorf("error scanning deck card: %w", err)
+		}
+		for _, size := range c.Sizes {
+			if url := firstNonEmpty(imageURIs[size], faceImageURIs[size]); url != "" {
+				targets = append(targets, target{cardID, size, url})
+			}
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error loading deck cards: %w", err)
+	}
+
+	var fetched, failed int
+	for _, t := range targets {
+		_, downloaded, err := c.Ensure(ctx, db, t.cardID, t.size, t.url)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			log.Printf("⚠️ Error caching %s image of %s: %v\n", t.size, t.cardID, err)
+			failed++
+			continue
+		}
+		if downloaded {
+			fetched++
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(prewarmInterval):
+			}
+		}
+	}
+
+	log.Printf("🖼️ Deck image prewarm: %d fetched, %d already cached, %d failed\n", fetched, len(targets)-fetched-failed, failed)
+	if failed > 0 {
+		return fmt.Errorf("%d of %d deck images could not be cached", failed, len(targets))
+	}
+	return nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -1,17 +1,33 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/quehorrifico/mana-tomb/backend/account"
+	"github.com/quehorrifico/mana-tomb/backend/admin"
+	"github.com/quehorrifico/mana-tomb/backend/autocomplete"
 	"github.com/quehorrifico/mana-tomb/backend/cards"
 	"github.com/quehorrifico/mana-tomb/backend/db"
 	"github.com/quehorrifico/mana-tomb/backend/decks"
+	"github.com/quehorrifico/mana-tomb/backend/imagecache"
 	"github.com/quehorrifico/mana-tomb/backend/middleware"
+	"github.com/quehorrifico/mana-tomb/backend/scryfall"
+	"github.com/quehorrifico/mana-tomb/backend/sets"
 	"github.com/quehorrifico/mana-tomb/backend/utils"
 )
 
+// shutdownTimeout is how long in-flight requests get to finish on shutdown.
+const shutdownTimeout = 10 * time.Second
+
 func withCORS(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		origin := r.Header.Get("Origin")
@@ -45,6 +61,21 @@ func registerRoutes(mux *http.ServeMux) {
 	// Card endpoints (Public)
 	mux.Handle("/card/random", withCORS(http.HandlerFunc(cards.GetRandomCard)))
 	mux.Handle("/card/", withCORS(http.HandlerFunc(cards.GetCardByName)))
+	mux.Handle("/card/{id}/rulings", withCORS(http.HandlerFunc(cards.GetCardRulings)))
+	mux.Handle("/card/{id}/prices", withCORS(http.HandlerFunc(cards.GetCardPrices)))
+	mux.Handle("/card/{id}/image/{size}", withCORS(http.HandlerFunc(cards.GetCardImage)))
+	mux.Handle("/cards/{id}", withCORS(http.HandlerFunc(cards.GetCardByID)))
+	mux.Handle("/cards/oracle/{oracle_id}", withCORS(http.HandlerFunc(cards.GetCardByOracleID)))
+	mux.Handle("/cards/oracle/{oracle_id}/printings", withCORS(http.HandlerFunc(cards.GetCardPrintings)))
+	mux.Handle("/cards/search", withCORS(http.HandlerFunc(cards.SearchCards)))
+	mux.Handle("/cards/collection", withCORS(http.HandlerFunc(cards.GetCardCollection)))
+	mux.Handle("/cards/autocomplete", withCORS(http.HandlerFunc(cards.Autocomplete)))
+	mux.Handle("/symbology", withCORS(http.HandlerFunc(cards.GetSymbology)))
+
+	// Set endpoints (Public)
+	mux.Handle("/sets", withCORS(http.HandlerFunc(sets.ListSets)))
+	mux.Handle("/sets/{code}", withCORS(http.HandlerFunc(sets.GetSet)))
+	mux.Handle("/sets/{code}/cards", withCORS(http.HandlerFunc(sets.GetSetCards)))
 
 	// Deck endpoints (Protected)
 	mux.Handle("/decks", withCORS(middleware.AuthMiddleware(http.HandlerFunc(decks.GetDecksByUser))))
@@ -53,6 +84,11 @@ func registerRoutes(mux *http.ServeMux) {
 	mux.Handle("/decks/update/", withCORS(middleware.AuthMiddleware(http.HandlerFunc(decks.UpdateDeck))))
 	mux.Handle("/decks/delete/", withCORS(middleware.AuthMiddleware(http.HandlerFunc(decks.DeleteDeck))))
 
+	// Admin endpoints (ADMIN_TOKEN bearer token)
+	mux.Handle("/api/bulk-update", withCORS(middleware.AdminMiddleware(http.HandlerFunc(admin.TriggerBulkUpdate))))
+	mux.Handle("/api/ingestion-runs", withCORS(middleware.AdminMiddleware(http.HandlerFunc(admin.ListIngestionRuns))))
+	mux.Handle("/api/ingestion-runs/{id}", withCORS(middleware.AdminMiddleware(http.HandlerFunc(admin.GetIngestionRun))))
+
 	// Auth endpoints (Public)
 	mux.Handle("/register", withCORS(http.HandlerFunc(account.RegisterUser)))
 	mux.Handle("/login", withCORS(http.HandlerFunc(account.LoginUser)))
@@ -60,24 +96,108 @@ func registerRoutes(mux *http.ServeMux) {
 	mux.Handle("/me", withCORS(http.HandlerFunc(account.GetCurrentUser)))
 }
 
+// runMigrateCommand applies (up), previews (dry-run) or lists (status) schema migrations.
+func runMigrateCommand(cmd string) error {
+	switch cmd {
+	case "up", "dry-run":
+		migrations, err := db.Migrate(db.GetDB(), cmd == "dry-run")
+		if err != nil {
+			return err
+		}
+		if len(migrations) == 0 {
+			log.Println("✅ Database schema is up to date")
+		}
+		return nil
+	case "status":
+		statuses, err := db.MigrationStatuses(db.GetDB())
+		if err != nil {
+			return err
+		}
+		for _, s := range statuses {
+			state := "pending"
+			if s.AppliedAt != nil {
+				state = "applied " + s.AppliedAt.Format("2006-01-02 15:04:05 MST")
+			}
+			fmt.Printf("%04d  %-32s %s\n", s.Version, s.Name, state)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown migrate command %q (want up, dry-run or status)", cmd)
+	}
+}
+
 func main() {
+	importFile := flag.String("import", "", "import a Scryfall bulk JSON file (optionally gzip-compressed) from local disk and exit")
+	importType := flag.String("import-type", "oracle_cards", "bulk data type of the file given to -import (oracle_cards, unique_artwork, default_cards, all_cards, rulings, sets, symbology)")
+	migrateCmd := flag.String("migrate", "", "run a schema migration command and exit: up, dry-run or status")
+	flag.Parse()
+
 	// 1) Connect to and open the final DB
 	db.Connect()
 	defer db.GetDB().Close()
 
+	// Migration commands: migrations otherwise run automatically at startup
+	if *migrateCmd != "" {
+		if err := runMigrateCommand(*migrateCmd); err != nil {
+			log.Fatalf("❌ Migration command failed: %v", err)
+		}
+		return
+	}
+
+	// Offline import mode: seed the card tables from a local file instead of serving
+	if *importFile != "" {
+		utils.SetupDatabase()
+		if _, err := utils.ImportBulkFile(db.GetDB(), *importType, *importFile); err != nil {
+			log.Fatalf("❌ Import failed: %v", err)
+		}
+		return
+	}
+
 	// 2) Inject DB references into packages
 	cards.DB = db.GetDB()
 	account.DB = db.GetDB()
 	decks.DB = db.GetDB()
 	middleware.DB = db.GetDB()
-
-	// 3) Initialize database schema and start daily card fetch job
-	utils.StartScheduler(db.GetDB())
+	admin.DB = db.GetDB()
+	sets.DB = db.GetDB()
+
+	// Cancelled on SIGINT/SIGTERM, which stops the scheduled jobs and the server
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	images, err := imagecache.NewFromEnv()
+	if err != nil {
+		log.Fatalf("❌ Invalid image cache configuration: %v", err)
+	}
+	cards.Images = images
+
+	// 3) Initialize database schema and start the scheduled jobs
+	source := scryfall.NewSourceFromEnv()
+	admin.Source = source
+	jobs, err := utils.StartScheduler(ctx, db.GetDB(), source, images)
+	if err != nil {
+		log.Fatalf("❌ Failed to start scheduler: %v", err)
+	}
+	autocomplete.Start(ctx, db.GetDB())
 
 	// 4) Setup HTTP routes and start the server
 	mux := http.NewServeMux()
 	registerRoutes(mux)
+	server := &http.Server{Addr: ":8080", Handler: mux}
 
-	log.Println("🚀 Server is running on port 8080")
-	log.Fatal(http.ListenAndServe(":8080", mux))
+	go func() {
+		log.Println("🚀 Server is running on port 8080")
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal(err)
+		}
+	}()
+
+	<-ctx.Done()
+	log.Println("🛑 Shutting down...")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Printf("⚠️ Error shutting down server: %v\n", err)
+	}
+	jobs.Wait()
 }
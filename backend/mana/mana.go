@@ -0,0 +1,253 @@
+// Package mana parses Magic mana costs like "{2}{W/U}{G/P}", and the symbols in oracle text, into
+// structured symbols with their colors and mana value.
+package mana
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Symbol types.
+const (
+	TypeGeneric   = "generic"   // {0}, {2}, {½}
+	TypeColored   = "colored"   // {W}, {U}, {B}, {R}, {G}, and the half symbols {HW} and {HR}
+	TypeColorless = "colorless" // {C}
+	TypeHybrid    = "hybrid"    // {W/U}, {2/W}, {C/W}
+	TypePhyrexian = "phyrexian" // {W/P}, {W/U/P}
+	TypeSnow      = "snow"      // {S}
+	TypeVariable  = "variable"  // {X}, {Y}, {Z}
+	TypeOther     = "other"     // non-mana symbols such as {T}, {Q} and {E}
+)
+
+// colorOrder is WUBRG, the order colors are listed in.
+const colorOrder = "WUBRG"
+
+// Symbol is one parsed symbol.
+type Symbol struct {
+	Symbol    string   `json:"symbol"` // as written, e.g. "{G/P}"
+	Type      string   `json:"type"`
+	Colors    []string `json:"colors,omitempty"` // colors of mana that can pay for it
+	ManaValue float64  `json:"mana_value"`
+	Hybrid    bool     `json:"hybrid,omitempty"`
+	Phyrexian bool     `json:"phyrexian,omitempty"`
+}
+
+// Pips counts the symbols of each color in a cost. A hybrid symbol counts once toward each of its
+// colors, as it does for devotion. C counts colorless symbols and S snow symbols.
+type Pips struct {
+	W int `json:"W"`
+	U int `json:"U"`
+	B int `json:"B"`
+	R int `json:"R"`
+	G int `json:"G"`
+	C int `json:"C"`
+	S int `json:"S"`
+}
+
+// Cost is a parsed mana cost.
+type Cost struct {
+	Symbols   []Symbol `json:"symbols"`
+	ManaValue float64  `json:"mana_value"`
+	Generic   float64  `json:"generic"` // total of the generic symbols
+	X         int      `json:"x"`       // number of variable symbols
+	Colors    []string `json:"colors"`  // colors appearing in the cost, in WUBRG order
+	Pips      Pips     `json:"pips"`
+}
+
+// ParseCost parses a mana cost. Costs of split and adventure cards, such as "{1}{R} // {1}{U}",
+// are parsed as a whole; their mana value is the total, as for the card itself.
+func ParseCost(cost string) (Cost, error) {
+	parsed := Cost{Symbols: []Symbol{}, Colors: []string{}}
+
+	rest := strings.TrimSpace(cost)
+	for rest != "" {
+		if after, ok := strings.CutPrefix(rest, "//"); ok {
+			rest = strings.TrimSpace(after)
+			continue
+		}
+		if rest[0] != '{' {
+			return Cost{}, fmt.Errorf("invalid mana cost %q: unexpected %q", cost, rest)
+		}
+		end := strings.IndexByte(rest, '}')
+		if end < 0 {
+			return Cost{}, fmt.Errorf("invalid mana cost %q: unclosed symbol", cost)
+		}
+		parsed.add(ParseSymbol(rest[:end+1]))
+		rest = strings.TrimSpace(rest[end+1:])
+	}
+
+	for _, c := range colorOrder {
+		if parsed.pip(string(c)) > 0 {
+			parsed.Colors = append(parsed.Colors, string(c))
+		}
+	}
+	return parsed, nil
+}
+
+// ParsedCost returns the parsed cost, or nil if cost is empty or invalid, for optional fields of
+// API responses.
+func ParsedCost(cost string) *Cost {
+	if strings.TrimSpace(cost) == "" {
+		return nil
+	}
+	parsed, err := ParseCost(cost)
+	if err != nil {
+		return nil
+	}
+	return &parsed
+}
+
+func (c *Cost) add(s Symbol) {
+	c.Symbols = append(c.Symbols, s)
+	c.ManaValue += s.ManaValue
+	switch s.Type {
+	case TypeGeneric:
+		c.Generic += s.ManaValue
+	case TypeVariable:
+		c.X++
+	case TypeColorless:
+		c.Pips.C++
+	case TypeSnow:
+		c.Pips.S++
+	}
+	for _, color := range s.Colors {
+		c.addPip(color)
+	}
+	// The colorless half of {C/W} counts as a colorless pip
+	if s.Hybrid && strings.Contains(s.Symbol, "C/") {
+		c.Pips.C++
+	}
+}
+
+func (c *Cost) addPip(color string) {
+	switch color {
+	case "W":
+		c.Pips.W++
+	case "U":
+		c.Pips.U++
+	case "B":
+		c.Pips.B++
+	case "R":
+		c.Pips.R++
+	case "G":
+		c.Pips.G++
+	}
+}
+
+func (c *Cost) pip(color string) int {
+	switch color {
+	case "W":
+		return c.Pips.W
+	case "U":
+		return c.Pips.U
+	case "B":
+		return c.Pips.B
+	case "R":
+		return c.Pips.R
+	case "G":
+		return c.Pips.G
+	}
+	return 0
+}
+
+// ParseText returns every symbol in a piece of oracle text, mana or not, in order. Text between
+// symbols is ignored.
+func ParseText(text string) []Symbol {
+	symbols := []Symbol{}
+	for {
+		start := strings.IndexByte(text, '{')
+		if start < 0 {
+			return symbols
+		}
+		end := strings.IndexByte(text[start:], '}')
+		if end < 0 {
+			return symbols
+		}
+		symbols = append(symbols, ParseSymbol(text[start:start+end+1]))
+		text = text[start+end+1:]
+	}
+}
+
+// ParseSymbol classifies one symbol written in braces, such as "{W/U}". Symbols it does not
+// recognise as mana get TypeOther.
+func ParseSymbol(symbol string) Symbol {
+	s := Symbol{Symbol: symbol, Type: TypeOther}
+	body := strings.ToUpper(strings.TrimSuffix(strings.TrimPrefix(symbol, "{"), "}"))
+
+	switch {
+	case body == "½":
+		s.Type, s.ManaValue = TypeGeneric, 0.5
+	case isNumber(body):
+		n, _ := strconv.Atoi(body)
+		s.Type, s.ManaValue = TypeGeneric, float64(n)
+	case isColor(body):
+		s.Type, s.Colors, s.ManaValue = TypeColored, []string{body}, 1
+	case len(body) == 2 && body[0] == 'H' && isColor(body[1:]):
+		// Half a mana of one color, as on Un-set cards like Little Girl
+		s.Type, s.Colors, s.ManaValue = TypeColored, []string{body[1:]}, 0.5
+	case body == "C":
+		s.Type, s.ManaValue = TypeColorless, 1
+	case body == "S":
+		s.Type, s.ManaValue = TypeSnow, 1
+	case body == "X" || body == "Y" || body == "Z":
+		s.Type = TypeVariable
+	case strings.Contains(body, "/"):
+		parseSplitSymbol(&s, strings.Split(body, "/"))
+	}
+	return s
+}
+
+// parseSplitSymbol classifies hybrid and Phyrexian symbols such as W/U, 2/W, C/W, W/P and W/U/P.
+func parseSplitSymbol(s *Symbol, parts []string) {
+	if parts[len(parts)-1] == "P" {
+		s.Phyrexian = true
+		parts = parts[:len(parts)-1]
+	}
+	if len(parts) == 0 || len(parts) > 2 {
+		s.Phyrexian = false
+		return
+	}
+
+	manaValue := 1.0
+	var colors []string
+	for _, part := range parts {
+		switch {
+		case isColor(part):
+			colors = append(colors, part)
+		case isNumber(part):
+			n, _ := strconv.Atoi(part)
+			manaValue = float64(n)
+		case part == "C":
+		default:
+			s.Phyrexian = false
+			return
+		}
+	}
+	if len(colors) == 0 {
+		s.Phyrexian = false
+		return
+	}
+
+	s.Colors, s.ManaValue, s.Hybrid = colors, manaValue, len(parts) == 2
+	s.Type = TypeHybrid
+	if s.Phyrexian {
+		s.Type = TypePhyrexian
+	}
+}
+
+func isColor(s string) bool {
+	return len(s) == 1 && strings.Contains(colorOrder, s)
+}
+
+func isNumber(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,128 @@
+package mana
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseSymbol(t *testing.T) {
+	for _, c := range []struct {
+		symbol string
+		want   Symbol
+	}{
+		{"{0}", Symbol{Type: TypeGeneric}},
+		{"{2}", Symbol{Type: TypeGeneric, ManaValue: 2}},
+		{"{16}", Symbol{Type: TypeGeneric, ManaValue: 16}},
+		{"{½}", Symbol{Type: TypeGeneric, ManaValue: 0.5}},
+		{"{W}", Symbol{Type: TypeColored, Colors: []string{"W"}, ManaValue: 1}},
+		{"{g}", Symbol{Type: TypeColored, Colors: []string{"G"}, ManaValue: 1}},
+		{"{HW}", Symbol{Type: TypeColored, Colors: []string{"W"}, ManaValue: 0.5}},
+		{"{HR}", Symbol{Type: TypeColored, Colors: []string{"R"}, ManaValue: 0.5}},
+		{"{C}", Symbol{Type: TypeColorless, ManaValue: 1}},
+		{"{S}", Symbol{Type: TypeSnow, ManaValue: 1}},
+		{"{X}", Symbol{Type: TypeVariable}},
+		{"{Y}", Symbol{Type: TypeVariable}},
+		{"{W/U}", Symbol{Type: TypeHybrid, Colors: []string{"W", "U"}, ManaValue: 1, Hybrid: true}},
+		{"{2/W}", Symbol{Type: TypeHybrid, Colors: []string{"W"}, ManaValue: 2, Hybrid: true}},
+		{"{C/W}", Symbol{Type: TypeHybrid, Colors: []string{"W"}, ManaValue: 1, Hybrid: true}},
+		{"{W/P}", Symbol{Type: TypePhyrexian, Colors: []string{"W"}, ManaValue: 1, Phyrexian: true}},
+		{"{W/U/P}", Symbol{Type: TypePhyrexian, Colors: []string{"W", "U"}, ManaValue: 1, Hybrid: true, Phyrexian: true}},
+		{"{T}", Symbol{Type: TypeOther}},
+		{"{Q}", Symbol{Type: TypeOther}},
+		{"{E}", Symbol{Type: TypeOther}},
+		{"{H}", Symbol{Type: TypeOther}},
+		{"{HC}", Symbol{Type: TypeOther}},
+		{"{P}", Symbol{Type: TypeOther}},
+		{"{2/P}", Symbol{Type: TypeOther}},
+		{"{W/U/B}", Symbol{Type: TypeOther}},
+		{"{/}", Symbol{Type: TypeOther}},
+	} {
+		c.want.Symbol = c.symbol
+		if got := ParseSymbol(c.symbol); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("ParseSymbol(%q) = %+v, want %+v", c.symbol, got, c.want)
+		}
+	}
+}
+
+func TestParseCost(t *testing.T) {
+	for _, c := range []struct {
+		cost      string
+		manaValue float64
+		generic   float64
+		x         int
+		colors    []string
+		pips      Pips
+		symbols   int
+	}{
+		{"", 0, 0, 0, []string{}, Pips{}, 0},
+		{"{0}", 0, 0, 0, []string{}, Pips{}, 1},
+		{"{2}{W}{W}", 4, 2, 0, []string{"W"}, Pips{W: 2}, 3},
+		{"{X}{R}{R}", 2, 0, 1, []string{"R"}, Pips{R: 2}, 3},
+		{"{G}{U}{W}", 3, 0, 0, []string{"W", "U", "G"}, Pips{W: 1, U: 1, G: 1}, 3},
+		{"{2/W}{2/W}{2/W}", 6, 0, 0, []string{"W"}, Pips{W: 3}, 3},
+		{"{W/U}{W/U}", 2, 0, 0, []string{"W", "U"}, Pips{W: 2, U: 2}, 2},
+		{"{C/W}", 1, 0, 0, []string{"W"}, Pips{W: 1, C: 1}, 1},
+		{"{3}{G/P}", 4, 3, 0, []string{"G"}, Pips{G: 1}, 2},
+		{"{1}{S}{S}", 3, 1, 0, []string{}, Pips{S: 2}, 3},
+		{"{C}{C}", 2, 0, 0, []string{}, Pips{C: 2}, 2},
+		{"{HW}", 0.5, 0, 0, []string{"W"}, Pips{W: 1}, 1},
+		{"{½}", 0.5, 0.5, 0, []string{}, Pips{}, 1},
+		{"{1}{R} // {1}{U}", 4, 2, 0, []string{"U", "R"}, Pips{U: 1, R: 1}, 4},
+		{" {B} ", 1, 0, 0, []string{"B"}, Pips{B: 1}, 1},
+	} {
+		got, err := ParseCost(c.cost)
+		if err != nil {
+			t.Errorf("ParseCost(%q): %v", c.cost, err)
+			continue
+		}
+		if got.ManaValue != c.manaValue || got.Generic != c.generic || got.X != c.x ||
+			!reflect.DeepEqual(got.Colors, c.colors) || got.Pips != c.pips || len(got.Symbols) != c.symbols {
+			t.Errorf("ParseCost(%q) = mana value %v, generic %v, x %d, colors %v, pips %+v, %d symbols; "+
+				"want %v, %v, %d, %v, %+v, %d", c.cost, got.ManaValue, got.Generic, got.X, got.Colors, got.Pips,
+				len(got.Symbols), c.manaValue, c.generic, c.x, c.colors, c.pips, c.symbols)
+		}
+	}
+}
+
+func TestParseCostErrors(t *testing.T) {
+	for _, cost := range []string{"2WW", "{2}W", "{2}{W", "{1} / {U}"} {
+		if _, err := ParseCost(cost); err == nil {
+			t.Errorf("ParseCost(%q) succeeded, want an error", cost)
+		}
+		if got := ParsedCost(cost); got != nil {
+			t.Errorf("ParsedCost(%q) = %+v, want nil", cost, got)
+		}
+	}
+	if got := ParsedCost("  "); got != nil {
+		t.Errorf("ParsedCost of a blank cost = %+v, want nil", got)
+	}
+}
+
+func TestParseText(t *testing.T) {
+	for _, c := range []struct {
+		text string
+		want []string
+	}{
+		{"Flying", []string{}},
+		{"{T}: Add {G}.", []string{"{T}", "{G}"}},
+		{"{2}{W/P}, {T}, Pay {E}{E}: Draw a card. ({W/P} can be paid with either {W} or 2 life.)",
+			[]string{"{2}", "{W/P}", "{T}", "{E}", "{E}", "{W/P}", "{W}"}},
+		{"Unclosed {G", []string{}},
+		{"{S}{HW} then { never closed", []string{"{S}", "{HW}"}},
+	} {
+		got := ParseText(c.text)
+		symbols := make([]string, len(got))
+		for i, s := range got {
+			symbols[i] = s.Symbol
+		}
+		if !reflect.DeepEqual(symbols, c.want) {
+			t.Errorf("ParseText(%q) = %v, want %v", c.text, symbols, c.want)
+		}
+	}
+	// Each symbol is classified the same way as on its own
+	for _, s := range ParseText("{T}: Add {C/W} or {S}.") {
+		if want := ParseSymbol(s.Symbol); !reflect.DeepEqual(s, want) {
+			t.Errorf("ParseText classified %s as %+v, want %+v", s.Symbol, s, want)
+		}
+	}
+}
@@ -0,0 +1,24 @@
+package models
+
+// CardFace is one face of a multi-faced card (transform, modal DFC, split, flip, adventure, ...).
+// Split, flip and adventure faces share the card's image, so their ImageURIs are nil.
+type CardFace struct {
+	Object          string               `json:"object"`
+	Name            string               `json:"name"`
+	ManaCost        string               `json:"mana_cost"`
+	TypeLine        string               `json:"type_line"`
+	OracleText      string               `json:"oracle_text"`
+	PrintedName     string               `json:"printed_name,omitempty"`
+	PrintedTypeLine string               `json:"printed_type_line,omitempty"`
+	PrintedText     string               `json:"printed_text,omitempty"`
+	Colors          []string             `json:"colors,omitempty"`
+	ColorIndicator  []string             `json:"color_indicator,omitempty"`
+	Power           string               `json:"power,omitempty"`
+	Toughness       string               `json:"toughness,omitempty"`
+	Loyalty         string               `json:"loyalty,omitempty"`
+	Defense         string               `json:"defense,omitempty"`
+	FlavorText      string               `json:"flavor_text,omitempty"`
+	Artist          string               `json:"artist,omitempty"`
+	IllustrationID  string               `json:"illustration_id,omitempty"`
+	ImageURIs       *OracleCardImageURIs `json:"image_uris,omitempty"`
+}
@@ -0,0 +1,25 @@
+package models
+
+// SymbologyResponse is Scryfall's list of card symbols, as returned by /symbology.
+type SymbologyResponse struct {
+	Object  string       `json:"object"`
+	HasMore bool         `json:"has_more"`
+	Data    []CardSymbol `json:"data"`
+}
+
+// CardSymbol is one symbol that can appear in mana costs or oracle text, such as {W/U} or {T}.
+type CardSymbol struct {
+	Symbol             string   `json:"symbol"`
+	SVGURI             string   `json:"svg_uri,omitempty"`
+	LooseVariant       string   `json:"loose_variant,omitempty"`
+	English            string   `json:"english"`
+	Transposable       bool     `json:"transposable"`
+	RepresentsMana     bool     `json:"represents_mana"`
+	AppearsInManaCosts bool     `json:"appears_in_mana_costs"`
+	ManaValue          float64  `json:"mana_value"`
+	Hybrid             bool     `json:"hybrid"`
+	Phyrexian          bool     `json:"phyrexian"`
+	Funny              bool     `json:"funny"`
+	Colors             []string `json:"colors"`
+	GathererAlternates []string `json:"gatherer_alternates,omitempty"`
+}
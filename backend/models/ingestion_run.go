@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// IngestionRun is one attempt to ingest a bulk file, as stored in ingestion_runs.
+type IngestionRun struct {
+	ID            int64               `json:"id"`
+	BulkType      string              `json:"bulk_type"`
+	BulkDataID    string              `json:"bulk_data_id,omitempty"`
+	Source        string              `json:"source"`  // download URI or local file path
+	Trigger       string              `json:"trigger"` // scheduled, manual or import
+	Status        string              `json:"status"`  // running, succeeded, not_modified or failed
+	StartedAt     time.Time           `json:"started_at"`
+	FinishedAt    *time.Time          `json:"finished_at,omitempty"`
+	Bytes         int64               `json:"bytes"`
+	CardsRead     int                 `json:"cards_read"`
+	CardsAdded    int                 `json:"cards_added"`
+	CardsChanged  int                 `json:"cards_changed"`
+	CardsRetired  int                 `json:"cards_retired"`
+	CardsRejected int                 `json:"cards_rejected"`
+	Error         string              `json:"error,omitempty"`
+	Errors        []IngestionRunError `json:"errors,omitempty"`
+}
+
+// IngestionRunError is a card an ingestion run rejected.
+type IngestionRunError struct {
+	CardID   string `json:"card_id"`
+	CardName string `json:"card_name"`
+	Reason   string `json:"reason"`
+}
@@ -103,4 +103,5 @@ type OracleCard struct {
 	StorySpotlight  bool                 `json:"story_spotlight"`
 	EDHRecRank      int                  `json:"edhrec_rank"`
 	Prices          OracleCardPrices     `json:"prices"`
+	CardFaces       []CardFace           `json:"card_faces,omitempty"`
 }
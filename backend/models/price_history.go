@@ -0,0 +1,22 @@
+package models
+
+// PriceSnapshot is the price of one printing on one day. Nil prices were not available that day.
+type PriceSnapshot struct {
+	Date      string   `json:"date"`
+	USD       *float64 `json:"usd"`
+	USDFoil   *float64 `json:"usd_foil"`
+	USDEtched *float64 `json:"usd_etched"`
+	EUR       *float64 `json:"eur"`
+	EURFoil   *float64 `json:"eur_foil"`
+	Tix       *float64 `json:"tix"`
+}
+
+// PriceTrend summarises one currency/finish of a price series. ChangePercent compares the last
+// price with the first; it is nil when the series has no prices or starts at zero.
+type PriceTrend struct {
+	Min           *float64 `json:"min"`
+	Max           *float64 `json:"max"`
+	First         *float64 `json:"first"`
+	Last          *float64 `json:"last"`
+	ChangePercent *float64 `json:"change_percent"`
+}
@@ -0,0 +1,52 @@
+package models
+
+// Printing is one physical or digital printing of a card, as found in the default_cards and
+// all_cards bulk files. Printings of the same card share an OracleID. Non-English printings carry
+// the text as printed in their language in the Printed fields.
+type Printing struct {
+	ID              string              `json:"id"`
+	OracleID        string              `json:"oracle_id"`
+	MultiverseIDs   []int               `json:"multiverse_ids"`
+	MTGOID          int                 `json:"mtgo_id"`
+	ArenaID         int                 `json:"arena_id"`
+	TCGPlayerID     int                 `json:"tcgplayer_id"`
+	CardMarketID    int                 `json:"cardmarket_id"`
+	Name            string              `json:"name"`
+	Lang            string              `json:"lang"`
+	PrintedName     string              `json:"printed_name,omitempty"`
+	PrintedTypeLine string              `json:"printed_type_line,omitempty"`
+	PrintedText     string              `json:"printed_text,omitempty"`
+	ReleasedAt      string              `json:"released_at"`
+	ScryfallURI     string              `json:"scryfall_uri"`
+	Layout          string              `json:"layout"`
+	ImageURIs       OracleCardImageURIs `json:"image_uris"`
+	ManaCost        string              `json:"mana_cost"`
+	TypeLine        string              `json:"type_line"`
+	Games           []string            `json:"games"`
+	Foil            bool                `json:"foil"`
+	NonFoil         bool                `json:"nonfoil"`
+	Finishes        []string            `json:"finishes"`
+	Oversized       bool                `json:"oversized"`
+	Promo           bool                `json:"promo"`
+	PromoTypes      []string            `json:"promo_types"`
+	Reprint         bool                `json:"reprint"`
+	Variation       bool                `json:"variation"`
+	VariationOf     string              `json:"variation_of"`
+	SetID           string              `json:"set_id"`
+	Set             string              `json:"set"`
+	SetName         string              `json:"set_name"`
+	SetType         string              `json:"set_type"`
+	CollectorNumber string              `json:"collector_number"`
+	Digital         bool                `json:"digital"`
+	Rarity          string              `json:"rarity"`
+	Artist          string              `json:"artist"`
+	IllustrationID  string              `json:"illustration_id"`
+	BorderColor     string              `json:"border_color"`
+	Frame           string              `json:"frame"`
+	FrameEffects    []string            `json:"frame_effects"`
+	FullArt         bool                `json:"full_art"`
+	Textless        bool                `json:"textless"`
+	Booster         bool                `json:"booster"`
+	Prices          OracleCardPrices    `json:"prices"`
+	CardFaces       []CardFace          `json:"card_faces,omitempty"`
+}
@@ -0,0 +1,11 @@
+package models
+
+// Ruling is an official ruling or note on a card, from Scryfall's rulings bulk file.
+// Rulings belong to every printing of a card, so they are keyed by OracleID.
+type Ruling struct {
+	Object      string `json:"object,omitempty"`
+	OracleID    string `json:"oracle_id"`
+	Source      string `json:"source"` // "wotc" or "scryfall"
+	PublishedAt string `json:"published_at"`
+	Comment     string `json:"comment"`
+}
@@ -0,0 +1,33 @@
+package models
+
+// SetListResponse is Scryfall's list of sets, as returned by /sets.
+type SetListResponse struct {
+	Object   string `json:"object"`
+	HasMore  bool   `json:"has_more"`
+	NextPage string `json:"next_page,omitempty"`
+	Data     []Set  `json:"data"`
+}
+
+// Set is one Magic set from Scryfall's set catalog.
+type Set struct {
+	ID            string `json:"id"`
+	Code          string `json:"code"`
+	MTGOCode      string `json:"mtgo_code,omitempty"`
+	ArenaCode     string `json:"arena_code,omitempty"`
+	TCGPlayerID   int    `json:"tcgplayer_id,omitempty"`
+	Name          string `json:"name"`
+	SetType       string `json:"set_type"`
+	ReleasedAt    string `json:"released_at,omitempty"`
+	BlockCode     string `json:"block_code,omitempty"`
+	Block         string `json:"block,omitempty"`
+	ParentSetCode string `json:"parent_set_code,omitempty"`
+	CardCount     int    `json:"card_count"`
+	PrintedSize   int    `json:"printed_size,omitempty"`
+	Digital       bool   `json:"digital"`
+	FoilOnly      bool   `json:"foil_only"`
+	NonFoilOnly   bool   `json:"nonfoil_only"`
+	ScryfallURI   string `json:"scryfall_uri"`
+	URI           string `json:"uri"`
+	IconSVGURI    string `json:"icon_svg_uri"`
+	SearchURI     string `json:"search_uri"`
+}
@@ -120,4 +120,5 @@ type UniqueArtworkCard struct {
 	PennyRank       int                      `json:"penny_rank"`
 	Prices          UniqueArtworkPrices      `json:"prices"`
 	RelatedURIs     UniqueArtworkRelatedURIs `json:"related_uris"`
+	CardFaces       []CardFace               `json:"card_faces,omitempty"`
 }
@@ -0,0 +1,157 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+func date(s string) time.Time {
+	t, err := time.Parse("2006-01-02 15:04", s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestParseScheduleNext(t *testing.T) {
+	tests := []struct {
+		spec  string
+		after string
+		want  string
+	}{
+		// Plain values, wildcards and descriptors
+		{"0 12 * * *", "2025-03-10 11:59", "2025-03-10 12:00"},
+		{"0 12 * * *", "2025-03-10 12:00", "2025-03-11 12:00"},
+		{"30 * * * *", "2025-03-10 11:45", "2025-03-10 12:30"},
+		{"@hourly", "2025-03-10 11:45", "2025-03-10 12:00"},
+		{"@daily", "2025-03-10 11:45", "2025-03-11 00:00"},
+		{"@midnight", "2025-12-31 23:59", "2026-01-01 00:00"},
+		{"@weekly", "2025-03-10 11:45", "2025-03-16 00:00"}, // the next Sunday
+		{"@monthly", "2025-03-10 11:45", "2025-04-01 00:00"},
+
+		// Ranges, steps and lists
+		{"*/15 * * * *", "2025-03-10 11:46", "2025-03-10 12:00"},
+		{"10-20/5 * * * *", "2025-03-10 11:16", "2025-03-10 11:20"},
+		{"10-20/5 * * * *", "2025-03-10 11:20", "2025-03-10 12:10"},
+		{"5/20 * * * *", "2025-03-10 11:26", "2025-03-10 11:45"},
+		{"0 9-17 * * 1-5", "2025-03-14 17:00", "2025-03-17 09:00"}, // Friday evening to Monday morning
+		{"0 0,12 * * *", "2025-03-10 00:00", "2025-03-10 12:00"},
+		{"0 0 * * 7", "2025-03-10 11:45", "2025-03-16 00:00"}, // 7 is Sunday too
+
+		// Month rollover, including months without the day
+		{"0 0 1 * *", "2025-01-31 23:59", "2025-02-01 00:00"},
+		{"0 0 31 * *", "2025-04-01 00:00", "2025-05-31 00:00"},
+		{"0 0 29 2 *", "2025-03-01 00:00", "2028-02-29 00:00"},
+		{"0 0 1 1 *", "2025-06-15 08:00", "2026-01-01 00:00"},
+
+		// Both day fields restricted: either one matching is enough
+		{"0 0 13 * 5", "2025-06-01 00:00", "2025-06-06 00:00"}, // Friday the 6th, before the 13th
+		{"0 0 13 * 5", "2025-06-12 00:00", "2025-06-13 00:00"}, // the 13th, a Friday anyway
+		{"0 0 1 * 1", "2025-09-01 00:00", "2025-09-08 00:00"},  // a Monday before the next 1st
+		// Only one restricted: the other does not widen the match
+		{"0 0 13 * *", "2025-06-01 00:00", "2025-06-13 00:00"},
+		{"0 0 * * 5", "2025-06-07 00:00", "2025-06-13 00:00"},
+		// A day field starting with "*", even with a step, makes both fields have to match
+		{"0 3 */2 * 1", "2025-06-01 00:00", "2025-06-09 03:00"}, // the 2nd is a Monday but even
+		{"0 3 */2 * 1", "2025-06-09 03:00", "2025-06-23 03:00"},
+		{"0 0 13 * */2", "2025-06-01 00:00", "2025-07-13 00:00"}, // the first 13th on a Sunday, Tuesday, Thursday or Saturday
+	}
+	for _, tt := range tests {
+		schedule, err := ParseSchedule(tt.spec)
+		if err != nil {
+			t.Errorf("ParseSchedule(%q): %v", tt.spec, err)
+			continue
+		}
+		if got := schedule.Next(date(tt.after)); !got.Equal(date(tt.want)) {
+			t.Errorf("%q: Next(%s) = %s, want %s", tt.spec, tt.after, got.Format("2006-01-02 15:04 Mon"), tt.want)
+		}
+	}
+}
+
+func TestNextIsUTCAndStrictlyAfter(t *testing.T) {
+	schedule, err := ParseSchedule("0 12 * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 12:00:30 UTC is inside the 12:00 slot, so the next run is the next day
+	after := time.Date(2025, 3, 10, 13, 0, 30, 0, time.FixedZone("CET", 3600))
+	if got, want := schedule.Next(after), date("2025-03-11 12:00"); !got.Equal(want) || got.Location() != time.UTC {
+		t.Errorf("Next(%s) = %s, want %s UTC", after, got, want)
+	}
+}
+
+func TestEverySchedule(t *testing.T) {
+	schedule, err := ParseSchedule("@every 6h")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := schedule.Next(date("2025-03-10 11:45")), date("2025-03-10 17:45"); !got.Equal(want) {
+		t.Errorf("Next = %s, want %s", got, want)
+	}
+}
+
+func TestParseScheduleErrors(t *testing.T) {
+	for _, spec := range []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * 32 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"a * * * *",
+		"1-x * * * *",
+		"@yearly",
+		"@every 30s",
+		"@every soon",
+	} {
+		if _, err := ParseSchedule(spec); err == nil {
+			t.Errorf("ParseSchedule(%q) succeeded, want an error", spec)
+		}
+	}
+}
+
+func TestCatchUp(t *testing.T) {
+	daily, err := ParseSchedule("0 12 * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := date("2025-03-10 15:00")
+	tests := []struct {
+		name      string
+		last      time.Time
+		wantFirst time.Time
+		wantDue   bool
+	}{
+		{"never ran", time.Time{}, now, true},
+		{"ran this slot", date("2025-03-10 12:00"), date("2025-03-11 12:00"), false},
+		{"missed today's slot", date("2025-03-09 12:00"), now, true},
+		{"missed several slots", date("2025-02-01 12:00"), now, true},
+	}
+	for _, tt := range tests {
+		if got := firstRun(daily, tt.last, now); !got.Equal(tt.wantFirst) {
+			t.Errorf("%s: firstRun = %s, want %s", tt.name, got, tt.wantFirst)
+		}
+		if got := due(daily, tt.last, now); got != tt.wantDue {
+			t.Errorf("%s: due = %v, want %v", tt.name, got, tt.wantDue)
+		}
+	}
+}
+
+func TestCatchUpAtSlotBoundary(t *testing.T) {
+	daily, err := ParseSchedule("0 12 * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	last, now := date("2025-03-09 12:00"), date("2025-03-10 12:00")
+	if got := firstRun(daily, last, now); !got.Equal(now) {
+		t.Errorf("firstRun = %s, want %s", got, now)
+	}
+	if !due(daily, last, now) {
+		t.Error("a run is not due when its slot starts exactly now")
+	}
+}
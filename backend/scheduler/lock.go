@@ -0,0 +1,43 @@
+package scheduler
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"log"
+)
+
+// lockNamespace is the first key of every advisory lock taken by TryLock, keeping them apart from
+// other advisory locks such as the one held while migrating.
+const lockNamespace = 7261002
+
+// TryLock takes the Postgres advisory lock called name without waiting. It reports false if
+// another session, in this process or another instance, already holds it. The lock is held by a
+// dedicated connection until release is called.
+func TryLock(ctx context.Context, db *sql.DB, name string) (release func(), acquired bool, err error) {
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, false, fmt.Errorf("error acquiring connection: %w", err)
+	}
+
+	err = conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock($1, hashtext($2))`, lockNamespace, name).Scan(&acquired)
+	if err != nil || !acquired {
+		conn.Close()
+		if err != nil {
+			return nil, false, fmt.Errorf("error taking lock %s: %w", name, err)
+		}
+		return nil, false, nil
+	}
+
+	release = func() {
+		// Unlock even when ctx was cancelled
+		if _, err := conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1, hashtext($2))`, lockNamespace, name); err != nil {
+			log.Printf("⚠️ Error releasing lock %s: %v\n", name, err)
+			// Discard the connection so the pool never hands out a session still holding the lock
+			conn.Raw(func(any) error { return driver.ErrBadConn })
+		}
+		conn.Close()
+	}
+	return release, true, nil
+}
@@ -0,0 +1,215 @@
+// Package scheduler runs recurring jobs on cron-style schedules. Each run of a job holds a
+// Postgres advisory lock named after the job, so when several backend instances share a database
+// only one of them runs it. The start of every run is stored in scheduled_jobs, which lets a
+// restarted instance catch up on a run it missed while it was down.
+package scheduler
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// Job statuses, stored in scheduled_jobs.last_status.
+const (
+	statusRunning   = "running"
+	statusSucceeded = "succeeded"
+	statusFailed    = "failed"
+	statusSkipped   = "skipped"
+)
+
+// ErrSkipped is returned, wrapped, by a job that found nothing to do because its work is already
+// being done elsewhere. The run is recorded as skipped rather than failed.
+var ErrSkipped = errors.New("run skipped")
+
+// Job is a named piece of work run on a schedule. Run must return promptly once ctx is cancelled.
+type Job struct {
+	Name     string
+	Schedule Schedule
+	Run      func(ctx context.Context) error
+}
+
+// Scheduler runs jobs until its context is cancelled.
+type Scheduler struct {
+	db     *sql.DB
+	jitter time.Duration
+	jobs   []Job
+	wg     sync.WaitGroup
+}
+
+// New returns a scheduler that delays each run by a random duration of up to jitter, so that
+// instances sharing a schedule do not all wake up at the same moment.
+func New(db *sql.DB, jitter time.Duration) *Scheduler {
+	return &Scheduler{db: db, jitter: jitter}
+}
+
+// Add registers a job. Jobs must be added before Start.
+func (s *Scheduler) Add(job Job) {
+	s.jobs = append(s.jobs, job)
+}
+
+// Start runs every job on its schedule in the background until ctx is cancelled.
+func (s *Scheduler) Start(ctx context.Context) {
+	for _, job := range s.jobs {
+		s.wg.Add(1)
+		go func(job Job) {
+			defer s.wg.Done()
+			s.loop(ctx, job)
+		}(job)
+	}
+}
+
+// Wait blocks until every job has stopped after the context given to Start was cancelled.
+func (s *Scheduler) Wait() {
+	s.wg.Wait()
+}
+
+func (s *Scheduler) loop(ctx context.Context, job Job) {
+	last, err := s.lastStarted(ctx, job.Name)
+	if err != nil {
+		log.Printf("⚠️ Error loading last run of %s, running it now: %v\n", job.Name, err)
+	}
+	next := firstRun(job.Schedule, last, time.Now())
+
+	for {
+		if next.IsZero() {
+			log.Printf("🛑 %s has no upcoming run\n", job.Name)
+			return
+		}
+		delay := time.Until(next)
+		if delay < 0 {
+			delay = 0
+		}
+		if s.jitter > 0 {
+			delay += time.Duration(rand.Int63n(int64(s.jitter)))
+		}
+		log.Printf("⏳ Next %s run at %s\n", job.Name, time.Now().Add(delay).UTC().Format(time.RFC3339))
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			log.Printf("🛑 Stopped %s\n", job.Name)
+			return
+		case <-timer.C:
+		}
+
+		s.runOnce(ctx, job)
+		next = job.Schedule.Next(time.Now())
+	}
+}
+
+// firstRun returns when a job whose last run started at last (zero if it never ran) should first
+// run after an instance starts at now. A job that never ran, or whose next scheduled run passed
+// while no instance was up, runs at once.
+func firstRun(schedule Schedule, last, now time.Time) time.Time {
+	if last.IsZero() {
+		return now
+	}
+	next := schedule.Next(last)
+	if !next.IsZero() && next.Before(now) {
+		return now
+	}
+	return next
+}
+
+// due reports whether a job whose last run started at last (zero if it never ran) is due at now,
+// that is whether no run has started yet for the current slot of its schedule.
+func due(schedule Schedule, last, now time.Time) bool {
+	return last.IsZero() || !schedule.Next(last).After(now)
+}
+
+// runOnce runs job if no other instance is running it and it has not already run for the
+// current slot of its schedule.
+func (s *Scheduler) runOnce(ctx context.Context, job Job) {
+	release, acquired, err := TryLock(ctx, s.db, "job:"+job.Name)
+	if err != nil {
+		log.Printf("❌ Error locking %s: %v\n", job.Name, err)
+		return
+	}
+	if !acquired {
+		log.Printf("⏭️ %s is already running on another instance\n", job.Name)
+		return
+	}
+	defer release()
+
+	// Another instance may have run this slot while this one was waiting
+	last, err := s.lastStarted(ctx, job.Name)
+	if err != nil {
+		log.Printf("❌ Error loading last run of %s: %v\n", job.Name, err)
+		return
+	}
+	if !due(job.Schedule, last, time.Now()) {
+		log.Printf("⏭️ %s already ran at %s\n", job.Name, last.UTC().Format(time.RFC3339))
+		return
+	}
+
+	if err := s.recordStart(ctx, job.Name); err != nil {
+		log.Printf("❌ Error recording start of %s: %v\n", job.Name, err)
+		return
+	}
+	log.Printf("▶️ Running %s\n", job.Name)
+	runErr := job.Run(ctx)
+	switch {
+	case errors.Is(runErr, ErrSkipped):
+		log.Printf("⏭️ %s skipped: %v\n", job.Name, runErr)
+	case runErr != nil:
+		log.Printf("❌ %s failed: %v\n", job.Name, runErr)
+	default:
+		log.Printf("✅ %s finished\n", job.Name)
+	}
+	if err := s.recordFinish(job.Name, runErr); err != nil {
+		log.Printf("⚠️ Error recording end of %s: %v\n", job.Name, err)
+	}
+}
+
+func (s *Scheduler) lastStarted(ctx context.Context, name string) (time.Time, error) {
+	var last time.Time
+	err := s.db.QueryRowContext(ctx, `SELECT last_started_at FROM scheduled_jobs WHERE name = $1`, name).Scan(&last)
+	if errors.Is(err, sql.ErrNoRows) {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("error reading scheduled_jobs: %w", err)
+	}
+	return last, nil
+}
+
+func (s *Scheduler) recordStart(ctx context.Context, name string) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO scheduled_jobs (name, last_started_at, last_status)
+		VALUES ($1, now(), $2)
+		ON CONFLICT (name) DO UPDATE
+		SET last_started_at = EXCLUDED.last_started_at, last_finished_at = NULL,
+		    last_status = EXCLUDED.last_status, last_error = NULL;
+	`, name, statusRunning)
+	return err
+}
+
+// recordFinish does not take a context: the outcome of a run cut short by shutdown is still stored.
+func (s *Scheduler) recordFinish(name string, runErr error) error {
+	status, errText := runStatus(runErr)
+	_, err := s.db.Exec(`
+		UPDATE scheduled_jobs
+		SET last_finished_at = now(), last_status = $2, last_error = $3
+		WHERE name = $1;
+	`, name, status, errText)
+	return err
+}
+
+// runStatus returns the status and error text stored for a run that returned runErr.
+func runStatus(runErr error) (status string, errText interface{}) {
+	switch {
+	case errors.Is(runErr, ErrSkipped):
+		return statusSkipped, nil
+	case runErr != nil:
+		return statusFailed, runErr.Error()
+	default:
+		return statusSucceeded, nil
+	}
+}
@@ -0,0 +1,25 @@
+package scheduler
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestRunStatus(t *testing.T) {
+	for _, c := range []struct {
+		err     error
+		status  string
+		errText interface{}
+	}{
+		{nil, statusSucceeded, nil},
+		{errors.New("download failed"), statusFailed, "download failed"},
+		{ErrSkipped, statusSkipped, nil},
+		{fmt.Errorf("%w: a bulk data refresh is already running", ErrSkipped), statusSkipped, nil},
+	} {
+		status, errText := runStatus(c.err)
+		if status != c.status || errText != c.errText {
+			t.Errorf("runStatus(%v) = %s, %v, want %s, %v", c.err, status, errText, c.status, c.errText)
+		}
+	}
+}
@@ -0,0 +1,213 @@
+// Package scryfalltest provides a local fake of the Scryfall API for exercising ingestion without
+// network access.
+package scryfalltest
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/quehorrifico/mana-tomb/backend/models"
+	"github.com/quehorrifico/mana-tomb/backend/scryfall"
+)
+
+// DefaultUpdatedAt is the updated_at reported for every bulk file until SetUpdatedAt changes it.
+var DefaultUpdatedAt = time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+
+// Server is a fake Scryfall API backed by fixture files. Every "<type>.json" or "<type>.json.gz"
+// file at the root of the fixtures becomes one item of the bulk data manifest, e.g.
+// oracle_cards.json is served as the oracle_cards bulk file. Downloads honour If-None-Match and
+// If-Modified-Since, so conditional refreshes can be exercised too. sets.json and symbology.json
+// are not bulk files: they are served as the /sets and /symbology lists.
+type Server struct {
+	*httptest.Server
+
+	fixtures fs.FS
+
+	mu        sync.Mutex
+	updatedAt map[string]time.Time
+	downloads map[string]int
+}
+
+// NewServer starts a fake Scryfall API serving fixtures, e.g. os.DirFS("fixtures").
+// The caller must call Close when done.
+func NewServer(fixtures fs.FS) *Server {
+	s := &Server{
+		fixtures:  fixtures,
+		updatedAt: make(map[string]time.Time),
+		downloads: make(map[string]int),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/bulk-data", s.serveManifest)
+	mux.HandleFunc("/file/", s.serveFile)
+	mux.HandleFunc("/sets", s.serveList("sets.json"))
+	mux.HandleFunc("/symbology", s.serveList("symbology.json"))
+	s.Server = httptest.NewServer(mux)
+	return s
+}
+
+// Source returns a CardSource that talks to this server.
+func (s *Server) Source() *scryfall.HTTPSource {
+	src := scryfall.NewHTTPSource(s.URL)
+	src.Client = s.Client()
+	return src
+}
+
+// SetUpdatedAt changes the updated_at (and Last-Modified) reported for bulkType, simulating a new
+// Scryfall export.
+func (s *Server) SetUpdatedAt(bulkType string, t time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.updatedAt[bulkType] = t.UTC()
+}
+
+// Downloads reports how many times the bulk file for bulkType was served in full (status 200).
+func (s *Server) Downloads(bulkType string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.downloads[bulkType]
+}
+
+func (s *Server) serveManifest(w http.ResponseWriter, r *http.Request) {
+	entries, err := fs.ReadDir(s.fixtures, ".")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	resp := models.BulkDataResponse{Object: "list", Data: []models.BulkData{}}
+	for _, entry := range entries {
+		bulkType, ok := bulkTypeOf(entry.Name())
+		if entry.IsDir() || !ok || apiFixtures[bulkType] {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		item := models.BulkData{
+			ID:          fakeUUID(bulkType),
+			URI:         s.URL + "/bulk-data/" + bulkType,
+			Type:        bulkType,
+			Name:        displayName(bulkType),
+			Description: "Fixture bulk file " + entry.Name(),
+			DownloadURI: s.URL + "/file/" + entry.Name(),
+			UpdatedAt:   s.updatedAtFor(bulkType).Format(time.RFC3339Nano),
+			Size:        int(info.Size()),
+			ContentType: "application/json",
+		}
+		if strings.HasSuffix(entry.Name(), ".gz") {
+			item.ContentEncoding = "gzip"
+		}
+		resp.Data = append(resp.Data, item)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+func (s *Server) serveFile(w http.ResponseWriter, r *http.Request) {
+	name := path.Base(r.URL.Path)
+	bulkType, ok := bulkTypeOf(name)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	data, err := fs.ReadFile(s.fixtures, name)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	sum := sha256.Sum256(data)
+	w.Header().Set("ETag", `"`+hex.EncodeToString(sum[:8])+`"`)
+	w.Header().Set("Content-Type", "application/json")
+
+	// ServeContent answers conditional requests against the ETag and modification time for us
+	rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+	http.ServeContent(rec, r, name, s.updatedAtFor(bulkType), bytes.NewReader(data))
+	if rec.status == http.StatusOK {
+		s.mu.Lock()
+		s.downloads[bulkType]++
+		s.mu.Unlock()
+	}
+}
+
+// serveList serves the fixture file name as is, or an empty list if there is no such fixture,
+// with an ETag of its contents that conditional requests are answered against.
+func (s *Server) serveList(name string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		data, err := fs.ReadFile(s.fixtures, name)
+		if err != nil {
+			data = []byte(`{"object":"list","has_more":false,"data":[]}`)
+		}
+		sum := sha256.Sum256(data)
+		w.Header().Set("ETag", `"`+hex.EncodeToString(sum[:8])+`"`)
+		w.Header().Set("Content-Type", "application/json")
+		http.ServeContent(w, r, name, time.Time{}, bytes.NewReader(data))
+	}
+}
+
+func (s *Server) updatedAtFor(bulkType string) time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if t, ok := s.updatedAt[bulkType]; ok {
+		return t
+	}
+	return DefaultUpdatedAt
+}
+
+// apiFixtures are fixture files served by an API endpoint of their own rather than as bulk files.
+var apiFixtures = map[string]bool{"sets": true, "symbology": true}
+
+// bulkTypeOf maps a fixture file name like "oracle_cards.json.gz" to its bulk type.
+func bulkTypeOf(name string) (string, bool) {
+	for _, ext := range []string{".json.gz", ".json"} {
+		if strings.HasSuffix(name, ext) {
+			return strings.TrimSuffix(name, ext), true
+		}
+	}
+	return "", false
+}
+
+// displayName turns "oracle_cards" into "Oracle Cards", like the names in Scryfall's manifest.
+func displayName(bulkType string) string {
+	words := strings.Split(bulkType, "_")
+	for i, w := range words {
+		if w != "" {
+			words[i] = strings.ToUpper(w[:1]) + w[1:]
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// fakeUUID derives a stable UUID-shaped ID from s so manifest IDs survive restarts.
+func fakeUUID(s string) string {
+	sum := md5.Sum([]byte(s))
+	h := hex.EncodeToString(sum[:])
+	return fmt.Sprintf("%s-%s-%s-%s-%s", h[0:8], h[8:12], h[12:16], h[16:20], h[20:32])
+}
+
+// statusRecorder remembers the status code written by http.ServeContent.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
@@ -0,0 +1,195 @@
+package scryfall
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/quehorrifico/mana-tomb/backend/models"
+)
+
+// DefaultBaseURL is the public Scryfall API.
+const DefaultBaseURL = "https://api.scryfall.com"
+
+// ErrNotModified is returned by OpenBulkFile when the server reports the file unchanged.
+var ErrNotModified = errors.New("bulk file not modified")
+
+// Validators are the HTTP cache validators of a previously downloaded bulk file.
+type Validators struct {
+	ETag         string
+	LastModified string
+}
+
+// CardSource provides the bulk data manifest, the bulk card files it points to, the set catalog
+// and the card symbology.
+type CardSource interface {
+	// BulkData returns every item of the bulk data manifest.
+	BulkData(ctx context.Context) ([]models.BulkData, error)
+	// OpenBulkFile opens the file for item. When prev is non-empty it is sent as a conditional
+	// request, and ErrNotModified is returned if the file has not changed. The returned
+	// Validators describe the opened file. The caller must close the reader.
+	OpenBulkFile(ctx context.Context, item models.BulkData, prev Validators) (io.ReadCloser, Validators, error)
+	// Sets returns every set of the set catalog. Like OpenBulkFile, it sends a non-empty prev as a
+	// conditional request and returns ErrNotModified if the catalog has not changed.
+	Sets(ctx context.Context, prev Validators) ([]models.Set, Validators, error)
+	// Symbology returns every card symbol, conditionally like Sets.
+	Symbology(ctx context.Context, prev Validators) ([]models.CardSymbol, Validators, error)
+}
+
+// HTTPSource reads card data from Scryfall, or any server speaking the same API.
+type HTTPSource struct {
+	BaseURL string
+	Client  *http.Client
+}
+
+// NewHTTPSource returns a source for the API rooted at baseURL, e.g. DefaultBaseURL.
+func NewHTTPSource(baseURL string) *HTTPSource {
+	return &HTTPSource{
+		BaseURL: strings.TrimRight(baseURL, "/"),
+		Client:  &http.Client{Timeout: 30 * time.Minute},
+	}
+}
+
+// NewSourceFromEnv returns a source for SCRYFALL_API_URL, falling back to DefaultBaseURL.
+func NewSourceFromEnv() *HTTPSource {
+	baseURL := os.Getenv("SCRYFALL_API_URL")
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	return NewHTTPSource(baseURL)
+}
+
+// BulkData fetches the bulk data manifest from {BaseURL}/bulk-data.
+func (s *HTTPSource) BulkData(ctx context.Context) ([]models.BulkData, error) {
+	resp, err := s.get(ctx, s.BaseURL+"/bulk-data", Validators{})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status fetching bulk data: %s", resp.Status)
+	}
+
+	var bulkDataResponse models.BulkDataResponse
+	if err := json.NewDecoder(resp.Body).Decode(&bulkDataResponse); err != nil {
+		return nil, fmt.Errorf("error decoding bulk data: %w", err)
+	}
+	return bulkDataResponse.Data, nil
+}
+
+// OpenBulkFile downloads item.DownloadURI, which may live on a different host than BaseURL.
+func (s *HTTPSource) OpenBulkFile(ctx context.Context, item models.BulkData, prev Validators) (io.ReadCloser, Validators, error) {
+	resp, err := s.get(ctx, item.DownloadURI, prev)
+	if err != nil {
+		return nil, Validators{}, err
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return resp.Body, responseValidators(resp), nil
+	case http.StatusNotModified:
+		resp.Body.Close()
+		return nil, prev, ErrNotModified
+	default:
+		resp.Body.Close()
+		return nil, Validators{}, fmt.Errorf("unexpected status fetching %s: %s", item.DownloadURI, resp.Status)
+	}
+}
+
+// Sets fetches the set catalog from {BaseURL}/sets, following next_page if the list is paginated.
+// prev is sent with the request for the first page, and ErrNotModified returned if it is unchanged.
+func (s *HTTPSource) Sets(ctx context.Context, prev Validators) ([]models.Set, Validators, error) {
+	var sets []models.Set
+	var validators Validators
+	for url := s.BaseURL + "/sets"; url != ""; {
+		resp, err := s.get(ctx, url, prev)
+		if err != nil {
+			return nil, Validators{}, err
+		}
+		if resp.StatusCode == http.StatusNotModified {
+			resp.Body.Close()
+			return nil, prev, ErrNotModified
+		}
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return nil, Validators{}, fmt.Errorf("unexpected status fetching sets: %s", resp.Status)
+		}
+		if validators == (Validators{}) {
+			validators = responseValidators(resp)
+		}
+
+		var page models.SetListResponse
+		err = json.NewDecoder(resp.Body).Decode(&page)
+		resp.Body.Close()
+		if err != nil {
+			return nil, Validators{}, fmt.Errorf("error decoding sets: %w", err)
+		}
+		sets = append(sets, page.Data...)
+
+		// Only the first page is conditional: once it changed, every page is fetched
+		url, prev = "", Validators{}
+		if page.HasMore {
+			url = page.NextPage
+		}
+	}
+	return sets, validators, nil
+}
+
+// Symbology fetches the card symbols from {BaseURL}/symbology, as a conditional request if prev
+// is non-empty.
+func (s *HTTPSource) Symbology(ctx context.Context, prev Validators) ([]models.CardSymbol, Validators, error) {
+	resp, err := s.get(ctx, s.BaseURL+"/symbology", prev)
+	if err != nil {
+		return nil, Validators{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotModified {
+		return nil, prev, ErrNotModified
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, Validators{}, fmt.Errorf("unexpected status fetching symbology: %s", resp.Status)
+	}
+
+	var symbology models.SymbologyResponse
+	if err := json.NewDecoder(resp.Body).Decode(&symbology); err != nil {
+		return nil, Validators{}, fmt.Errorf("error decoding symbology: %w", err)
+	}
+	return symbology.Data, responseValidators(resp), nil
+}
+
+// responseValidators returns the validators of a 200 response.
+func responseValidators(resp *http.Response) Validators {
+	return Validators{ETag: resp.Header.Get("ETag"), LastModified: resp.Header.Get("Last-Modified")}
+}
+
+// get issues a GET with the headers Scryfall asks API clients to send, plus any validators.
+func (s *HTTPSource) get(ctx context.Context, url string, prev Validators) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error building request: %w", err)
+	}
+	req.Header.Set("User-Agent", "ManaTomb/1.0")
+	req.Header.Set("Accept", "application/json;q=0.9,*/*;q=0.8")
+	if prev.ETag != "" {
+		req.Header.Set("If-None-Match", prev.ETag)
+	}
+	if prev.LastModified != "" {
+		req.Header.Set("If-Modified-Since", prev.LastModified)
+	}
+
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching %s: %w", url, err)
+	}
+	return resp, nil
+}
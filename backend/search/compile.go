@@ -0,0 +1,371 @@
+package search
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// colorsExpr is a card's colors. Scryfall leaves them off transform and other double-faced cards,
+// whose faces carry them instead.
+const colorsExpr = `COALESCE(colors, ARRAY(SELECT DISTINCT jsonb_array_elements_text(face->'colors') FROM jsonb_array_elements(card_faces) AS face))`
+
+// rarityExpr orders rarities the way Scryfall's r< and r> comparisons do.
+const rarityExpr = `CASE rarity WHEN 'common' THEN 0 WHEN 'uncommon' THEN 1 WHEN 'rare' THEN 2 WHEN 'special' THEN 3 WHEN 'mythic' THEN 4 WHEN 'bonus' THEN 5 END`
+
+// keys maps every keyword, including its aliases, to the function compiling its terms.
+var keys = map[string]func(*compiler, *Term) (string, error){
+	"name": (*compiler).name, "n": (*compiler).name,
+	"type": (*compiler).typeLine, "t": (*compiler).typeLine,
+	"oracle": (*compiler).oracle, "o": (*compiler).oracle,
+	"color": (*compiler).colors, "c": (*compiler).colors,
+	"identity": (*compiler).colors, "id": (*compiler).colors, "ci": (*compiler).colors,
+	"cmc": (*compiler).manaValue, "mv": (*compiler).manaValue, "manavalue": (*compiler).manaValue,
+	"format": (*compiler).legality, "f": (*compiler).legality, "legal": (*compiler).legality,
+	"banned": (*compiler).legality, "restricted": (*compiler).legality,
+	"rarity": (*compiler).rarity, "r": (*compiler).rarity,
+	"set": (*compiler).set, "s": (*compiler).set, "edition": (*compiler).set, "e": (*compiler).set,
+	"keyword": (*compiler).keyword, "kw": (*compiler).keyword,
+	"artist": (*compiler).artist, "a": (*compiler).artist,
+	"game": (*compiler).game,
+	"is":   (*compiler).is, "not": (*compiler).is,
+}
+
+// colorNames maps the color words accepted by c: and id: to their colors, WUBRG-ordered.
+var colorNames = map[string]string{
+	"white": "W", "blue": "U", "black": "B", "red": "R", "green": "G",
+	"azorius": "WU", "dimir": "UB", "rakdos": "BR", "gruul": "RG", "selesnya": "WG",
+	"orzhov": "WB", "izzet": "UR", "golgari": "BG", "boros": "WR", "simic": "UG",
+	"bant": "WUG", "esper": "WUB", "grixis": "UBR", "jund": "BRG", "naya": "WRG",
+	"abzan": "WBG", "jeskai": "WUR", "sultai": "UBG", "mardu": "WBR", "temur": "URG",
+}
+
+// rarities maps rarity names and their initials to the value used in rarityExpr.
+var rarities = map[string]int{
+	"common": 0, "c": 0, "uncommon": 1, "u": 1, "rare": 2, "r": 2,
+	"special": 3, "s": 3, "mythic": 4, "m": 4, "bonus": 5, "b": 5,
+}
+
+// formats are the keys of a card's legalities, plus "edh" for commander.
+var formats = map[string]string{
+	"standard": "standard", "future": "future", "historic": "historic", "timeless": "timeless",
+	"gladiator": "gladiator", "pioneer": "pioneer", "explorer": "explorer", "modern": "modern",
+	"legacy": "legacy", "pauper": "pauper", "vintage": "vintage", "penny": "penny",
+	"commander": "commander", "edh": "commander", "oathbreaker": "oathbreaker",
+	"standardbrawl": "standardbrawl", "brawl": "brawl", "alchemy": "alchemy",
+	"paupercommander": "paupercommander", "duel": "duel", "oldschool": "oldschool",
+	"premodern": "premodern", "predh": "predh",
+}
+
+// isConditions are the card properties tested by is: and not:.
+var isConditions = map[string]string{
+	"reserved":    `COALESCE(reserved, FALSE)`,
+	"gamechanger": `COALESCE(game_changer, FALSE)`,
+	"promo":       `COALESCE(promo, FALSE)`,
+	"reprint":     `COALESCE(reprint, FALSE)`,
+	"digital":     `COALESCE(digital, FALSE)`,
+	"fullart":     `COALESCE(full_art, FALSE)`,
+	"textless":    `COALESCE(textless, FALSE)`,
+	"spotlight":   `COALESCE(story_spotlight, FALSE)`,
+	"split":       `layout = 'split'`,
+	"flip":        `layout = 'flip'`,
+	"transform":   `layout = 'transform'`,
+	"meld":        `layout = 'meld'`,
+	"leveler":     `layout = 'leveler'`,
+	"adventure":   `layout = 'adventure'`,
+	"mdfc":        `layout = 'modal_dfc'`,
+	"dfc":         `layout IN ('transform', 'modal_dfc', 'meld', 'reversible_card')`,
+	"multicolor":  `cardinality(` + colorsExpr + `) > 1`,
+	"colorless":   `cardinality(` + colorsExpr + `) = 0`,
+	"commander": `((type_line ILIKE '%Legendary%Creature%' AND type_line NOT ILIKE '%//%') OR ` +
+		`COALESCE(oracle_text, '') ILIKE '%can be your commander%' OR ` +
+		`COALESCE(card_faces->0->>'type_line', '') ILIKE '%Legendary%Creature%')`,
+}
+
+// Compile compiles a parsed query to an SQL boolean expression over oracle_cards. Values are
+// passed as parameters numbered after the ones already in args, and returned appended to them.
+func Compile(node Node, args []interface{}) (string, []interface{}, error) {
+	c := &compiler{args: args}
+	where, err := c.compile(node)
+	if err != nil {
+		return "", nil, err
+	}
+	return where, c.args, nil
+}
+
+type compiler struct {
+	args []interface{}
+}
+
+// arg adds a parameter and returns its placeholder.
+func (c *compiler) arg(v interface{}) string {
+	c.args = append(c.args, v)
+	return "$" + strconv.Itoa(len(c.args))
+}
+
+func (c *compiler) compile(node Node) (string, error) {
+	switch n := node.(type) {
+	case *And:
+		return c.compileAll(n.Nodes, " AND ")
+	case *Or:
+		return c.compileAll(n.Nodes, " OR ")
+	case *Not:
+		inner, err := c.compile(n.Node)
+		if err != nil {
+			return "", err
+		}
+		// A comparison with a NULL column is NULL, which NOT leaves NULL; count it as no match
+		return "NOT COALESCE(" + inner + ", FALSE)", nil
+	case *Term:
+		if n.Key == "" {
+			return c.name(n)
+		}
+		compileKey, ok := keys[n.Key]
+		if !ok {
+			return "", &Error{Pos: n.Pos, Token: n.Key, Message: "unknown keyword '" + n.Key + "'"}
+		}
+		return compileKey(c, n)
+	default:
+		return "", fmt.Errorf("unknown query node %T", node)
+	}
+}
+
+func (c *compiler) compileAll(nodes []Node, sep string) (string, error) {
+	parts := make([]string, len(nodes))
+	for i, n := range nodes {
+		part, err := c.compile(n)
+		if err != nil {
+			return "", err
+		}
+		parts[i] = part
+	}
+	return "(" + strings.Join(parts, sep) + ")", nil
+}
+
+// name matches the card name, or the name of any face, by substring or, for !name, exactly.
+func (c *compiler) name(t *Term) (string, error) {
+	if err := onlyColon(t); err != nil {
+		return "", err
+	}
+	if t.Exact {
+		p := c.arg(t.Value)
+		return fmt.Sprintf(`(lower(name) = lower(%s) OR EXISTS (SELECT 1 FROM jsonb_array_elements(card_faces) AS face WHERE lower(face->>'name') = lower(%s)))`, p, p), nil
+	}
+	return `name ILIKE ` + c.arg(containsPattern(t.Value)), nil
+}
+
+func (c *compiler) typeLine(t *Term) (string, error) {
+	if err := onlyColon(t); err != nil {
+		return "", err
+	}
+	return `type_line ILIKE ` + c.arg(containsPattern(t.Value)), nil
+}
+
+// oracle matches rules text, including that of each face. As on Scryfall, "~" stands for the
+// card's own name.
+func (c *compiler) oracle(t *Term) (string, error) {
+	if err := onlyColon(t); err != nil {
+		return "", err
+	}
+	text, faceText := `COALESCE(oracle_text, '')`, `face->>'oracle_text'`
+	if strings.Contains(t.Value, "~") {
+		text, faceText = `replace(COALESCE(oracle_text, ''), name, '~')`, `replace(face->>'oracle_text', face->>'name', '~')`
+	}
+	p := c.arg(containsPattern(t.Value))
+	return fmt.Sprintf(`(%s ILIKE %s OR EXISTS (SELECT 1 FROM jsonb_array_elements(card_faces) AS face WHERE %s ILIKE %s))`, text, p, faceText, p), nil
+}
+
+// colors compares colors (c:) or color identity (id:) with a set of colors, a number of colors,
+// "colorless" or "multicolor". A bare ":" means "at least these colors" for c: but "within these
+// colors" for id:, which is how a commander deck's colors are searched.
+func (c *compiler) colors(t *Term) (string, error) {
+	expr, op := colorsExpr, t.Op
+	if t.Key == "c" || t.Key == "color" {
+		if op == ":" {
+			op = ">="
+		}
+	} else {
+		expr = `COALESCE(color_identity, '{}')`
+		if op == ":" {
+			op = "<="
+		}
+	}
+
+	value := strings.ToLower(t.Value)
+	if n, err := strconv.Atoi(value); err == nil {
+		return fmt.Sprintf(`cardinality(%s) %s %s`, expr, sqlOp(op), c.arg(n)), nil
+	}
+	switch value {
+	case "m", "multicolor":
+		if t.Op != ":" && t.Op != "=" {
+			return "", &Error{Pos: t.Pos, Token: t.String(), Message: "multicolor only supports ':'"}
+		}
+		return fmt.Sprintf(`cardinality(%s) > 1`, expr), nil
+	case "c", "colorless":
+		if op == ">=" || op == "=" || op == "<=" {
+			return fmt.Sprintf(`cardinality(%s) = 0`, expr), nil
+		}
+		if op == "!=" || op == ">" {
+			return fmt.Sprintf(`cardinality(%s) > 0`, expr), nil
+		}
+		return "FALSE", nil
+	}
+
+	colors, ok := colorNames[value]
+	if !ok {
+		colors = strings.ToUpper(value)
+		for _, r := range colors {
+			if !strings.ContainsRune("WUBRG", r) {
+				return "", &Error{Pos: t.Pos, Token: t.Value, Message: "'" + t.Value + "' is not a color; use letters from wubrg, a color name or c for colorless"}
+			}
+		}
+	}
+	set := strings.Split(colors, "")
+	sort.Strings(set)
+	p := c.arg(pq.Array(set))
+
+	contains, within := fmt.Sprintf(`%s @> %s::text[]`, expr, p), fmt.Sprintf(`%s <@ %s::text[]`, expr, p)
+	switch op {
+	case "=":
+		return "(" + contains + " AND " + within + ")", nil
+	case "!=":
+		return "NOT (" + contains + " AND " + within + ")", nil
+	case ">=":
+		return contains, nil
+	case "<=":
+		return within, nil
+	case ">":
+		return "(" + contains + " AND NOT " + within + ")", nil
+	default: // "<"
+		return "(" + within + " AND NOT " + contains + ")", nil
+	}
+}
+
+func (c *compiler) manaValue(t *Term) (string, error) {
+	v, err := strconv.ParseFloat(t.Value, 64)
+	if err != nil {
+		return "", &Error{Pos: t.Pos, Token: t.Value, Message: t.Key + " needs a number"}
+	}
+	return fmt.Sprintf(`cmc %s %s`, sqlOp(t.Op), c.arg(v)), nil
+}
+
+// legality matches cards legal (f:), banned or restricted in a format.
+func (c *compiler) legality(t *Term) (string, error) {
+	if err := equalityOp(t); err != nil {
+		return "", err
+	}
+	format, ok := formats[strings.ToLower(t.Value)]
+	if !ok {
+		return "", &Error{Pos: t.Pos, Token: t.Value, Message: "unknown format '" + t.Value + "'"}
+	}
+	status := "legal"
+	if t.Key == "banned" || t.Key == "restricted" {
+		status = t.Key
+	}
+	cond := fmt.Sprintf(`legalities->>%s = %s`, c.arg(format), c.arg(status))
+	if t.Op == "!=" {
+		return "NOT COALESCE(" + cond + ", FALSE)", nil
+	}
+	return cond, nil
+}
+
+func (c *compiler) rarity(t *Term) (string, error) {
+	rank, ok := rarities[strings.ToLower(t.Value)]
+	if !ok {
+		return "", &Error{Pos: t.Pos, Token: t.Value, Message: "unknown rarity '" + t.Value + "'"}
+	}
+	return fmt.Sprintf(`%s %s %s`, rarityExpr, sqlOp(t.Op), c.arg(rank)), nil
+}
+
+// set matches cards with a printing in a set, by set code. oracle_cards only records one
+// printing, so other printings are looked up in printings.
+func (c *compiler) set(t *Term) (string, error) {
+	if err := equalityOp(t); err != nil {
+		return "", err
+	}
+	p := c.arg(strings.ToLower(t.Value))
+	printed := fmt.Sprintf(`EXISTS (SELECT 1 FROM printings p WHERE p.oracle_id = oracle_cards.oracle_id AND p.set = %s AND p.retired_at IS NULL)`, p)
+	if t.Op == "!=" {
+		// A card without a set is not in the set, rather than unknown
+		return fmt.Sprintf(`(set IS DISTINCT FROM %s AND NOT %s)`, p, printed), nil
+	}
+	return fmt.Sprintf(`(set = %s OR %s)`, p, printed), nil
+}
+
+func (c *compiler) keyword(t *Term) (string, error) {
+	if err := onlyColon(t); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf(`EXISTS (SELECT 1 FROM unnest(keywords) AS k WHERE lower(k) = lower(%s))`, c.arg(t.Value)), nil
+}
+
+func (c *compiler) artist(t *Term) (string, error) {
+	if err := onlyColon(t); err != nil {
+		return "", err
+	}
+	return `artist ILIKE ` + c.arg(containsPattern(t.Value)), nil
+}
+
+// game matches cards available in paper, on Arena or on MTGO.
+func (c *compiler) game(t *Term) (string, error) {
+	if err := onlyColon(t); err != nil {
+		return "", err
+	}
+	game := strings.ToLower(t.Value)
+	if game != "paper" && game != "arena" && game != "mtgo" {
+		return "", &Error{Pos: t.Pos, Token: t.Value, Message: "unknown game '" + t.Value + "'; use paper, arena or mtgo"}
+	}
+	return fmt.Sprintf(`%s = ANY(games)`, c.arg(game)), nil
+}
+
+func (c *compiler) is(t *Term) (string, error) {
+	if t.Op != ":" {
+		return "", &Error{Pos: t.Pos, Token: t.String(), Message: t.Key + " only supports ':'"}
+	}
+	cond, ok := isConditions[strings.ToLower(t.Value)]
+	if !ok {
+		return "", &Error{Pos: t.Pos, Token: t.Value, Message: "unknown property '" + t.Value + "'"}
+	}
+	if t.Key == "not" {
+		return "NOT COALESCE(" + cond + ", FALSE)", nil
+	}
+	return cond, nil
+}
+
+// onlyColon rejects comparison operators on keywords whose values have no order.
+func onlyColon(t *Term) error {
+	if t.Op == "" || t.Op == ":" || t.Op == "=" {
+		return nil
+	}
+	return &Error{Pos: t.Pos, Token: t.String(), Message: "'" + t.Key + "' does not support '" + t.Op + "'"}
+}
+
+// equalityOp is onlyColon for keywords that also accept "!=".
+func equalityOp(t *Term) error {
+	if t.Op == "!=" {
+		return nil
+	}
+	return onlyColon(t)
+}
+
+// sqlOp maps a query operator to SQL; ":" compares for equality. "!=" matches a NULL column, as
+// negating "=" does.
+func sqlOp(op string) string {
+	switch op {
+	case ":", "=":
+		return "="
+	case "!=":
+		return "IS DISTINCT FROM"
+	default:
+		return op
+	}
+}
+
+// containsPattern is an ILIKE pattern matching value anywhere, with LIKE wildcards in value escaped.
+func containsPattern(value string) string {
+	r := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	return "%" + r.Replace(value) + "%"
+}
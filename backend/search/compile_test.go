@@ -0,0 +1,135 @@
+package search
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/lib/pq"
+)
+
+// setCond is the condition set:<code> compiles to, with the code as parameter $1.
+const setCond = `(set = $1 OR EXISTS (SELECT 1 FROM printings p WHERE p.oracle_id = oracle_cards.oracle_id AND p.set = $1 AND p.retired_at IS NULL))`
+
+func TestCompile(t *testing.T) {
+	for _, c := range []struct {
+		query string
+		where string
+		args  []interface{}
+	}{
+		{"bolt", `name ILIKE $1`, []interface{}{"%bolt%"}},
+		{"100%_\\", `name ILIKE $1`, []interface{}{`%100\%\_\\%`}},
+		{"!Opt", `(lower(name) = lower($1) OR EXISTS (SELECT 1 FROM jsonb_array_elements(card_faces) AS face WHERE lower(face->>'name') = lower($1)))`,
+			[]interface{}{"Opt"}},
+		{"t:creature", `type_line ILIKE $1`, []interface{}{"%creature%"}},
+		{`o:"draw a card"`, `(COALESCE(oracle_text, '') ILIKE $1 OR EXISTS (SELECT 1 FROM jsonb_array_elements(card_faces) AS face WHERE face->>'oracle_text' ILIKE $1))`,
+			[]interface{}{"%draw a card%"}},
+		{"o:~", `(replace(COALESCE(oracle_text, ''), name, '~') ILIKE $1 OR EXISTS (SELECT 1 FROM jsonb_array_elements(card_faces) AS face WHERE replace(face->>'oracle_text', face->>'name', '~') ILIKE $1))`,
+			[]interface{}{"%~%"}},
+		{"cmc<=3", `cmc <= $1`, []interface{}{3.0}},
+		{"mv:2.5", `cmc = $1`, []interface{}{2.5}},
+		{"cmc!=3", `cmc IS DISTINCT FROM $1`, []interface{}{3.0}},
+		{"c:rg", colorsExpr + ` @> $1::text[]`, []interface{}{pq.Array([]string{"G", "R"})}},
+		{"c=gruul", `(` + colorsExpr + ` @> $1::text[] AND ` + colorsExpr + ` <@ $1::text[])`, []interface{}{pq.Array([]string{"G", "R"})}},
+		{"id:esper", `COALESCE(color_identity, '{}') <@ $1::text[]`, []interface{}{pq.Array([]string{"B", "U", "W"})}},
+		{"c>=2", `cardinality(` + colorsExpr + `) >= $1`, []interface{}{2}},
+		{"c:c", `cardinality(` + colorsExpr + `) = 0`, nil},
+		{"c!=c", `cardinality(` + colorsExpr + `) > 0`, nil},
+		{"id:m", `cardinality(COALESCE(color_identity, '{}')) > 1`, nil},
+		{"f:edh", `legalities->>$1 = $2`, []interface{}{"commander", "legal"}},
+		{"banned:modern", `legalities->>$1 = $2`, []interface{}{"modern", "banned"}},
+		{"f!=pauper", `NOT COALESCE(legalities->>$1 = $2, FALSE)`, []interface{}{"pauper", "legal"}},
+		{"r>=rare", rarityExpr + ` >= $1`, []interface{}{2}},
+		{"r!=c", rarityExpr + ` IS DISTINCT FROM $1`, []interface{}{0}},
+		{"set:MH2", setCond, []interface{}{"mh2"}},
+		{"e=mh2", setCond, []interface{}{"mh2"}},
+		{"set!=mh2", `(set IS DISTINCT FROM $1 AND NOT EXISTS (SELECT 1 FROM printings p WHERE p.oracle_id = oracle_cards.oracle_id AND p.set = $1 AND p.retired_at IS NULL))`,
+			[]interface{}{"mh2"}},
+		{"kw:flying", `EXISTS (SELECT 1 FROM unnest(keywords) AS k WHERE lower(k) = lower($1))`, []interface{}{"flying"}},
+		{"a:rk", `artist ILIKE $1`, []interface{}{"%rk%"}},
+		{"game:Arena", `$1 = ANY(games)`, []interface{}{"arena"}},
+		{"is:reserved", `COALESCE(reserved, FALSE)`, nil},
+		{"not:mdfc", `NOT COALESCE(layout = 'modal_dfc', FALSE)`, nil},
+		{"t:elf c:g", `(type_line ILIKE $1 AND ` + colorsExpr + ` @> $2::text[])`, []interface{}{"%elf%", pq.Array([]string{"G"})}},
+		{"r:rare or r:mythic", `(` + rarityExpr + ` = $1 OR ` + rarityExpr + ` = $2)`, []interface{}{2, 4}},
+		{"-t:legendary", `NOT COALESCE(type_line ILIKE $1, FALSE)`, []interface{}{"%legendary%"}},
+		{"-(a or b)", `NOT COALESCE((name ILIKE $1 OR name ILIKE $2), FALSE)`, []interface{}{"%a%", "%b%"}},
+	} {
+		node, err := Parse(c.query)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", c.query, err)
+			continue
+		}
+		where, args, err := Compile(node, nil)
+		if err != nil {
+			t.Errorf("Compile(%q): %v", c.query, err)
+			continue
+		}
+		if where != c.where {
+			t.Errorf("Compile(%q) =\n\t%s\nwant\n\t%s", c.query, where, c.where)
+		}
+		if !reflect.DeepEqual(args, c.args) {
+			t.Errorf("Compile(%q) args = %#v, want %#v", c.query, args, c.args)
+		}
+	}
+}
+
+// TestCompileNumbersParametersAfterArgs checks the parameters of a query follow the ones the
+// caller already has.
+func TestCompileNumbersParametersAfterArgs(t *testing.T) {
+	node, err := Parse("t:elf cmc<2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	where, args, err := Compile(node, []interface{}{"first"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `(type_line ILIKE $2 AND cmc < $3)`; where != want {
+		t.Errorf("where = %s, want %s", where, want)
+	}
+	if want := []interface{}{"first", "%elf%", 2.0}; !reflect.DeepEqual(args, want) {
+		t.Errorf("args = %#v, want %#v", args, want)
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	for _, c := range []struct {
+		query   string
+		pos     int
+		message string
+	}{
+		{"foo:bar", 0, "unknown keyword 'foo'"},
+		{"x pow>3", 2, "unknown keyword 'pow'"},
+		{"t>creature", 0, "'t' does not support '>'"},
+		{"o!=draw", 0, "'o' does not support '!='"},
+		{"name<x", 0, "'name' does not support '<'"},
+		{"cmc:three", 0, "cmc needs a number"},
+		{"c:purple", 0, "'purple' is not a color"},
+		{"c>m", 0, "multicolor only supports ':'"},
+		{"f:casual", 0, "unknown format 'casual'"},
+		{"f<modern", 0, "'f' does not support '<'"},
+		{"r:legendary", 0, "unknown rarity 'legendary'"},
+		{"set>mh2", 0, "'set' does not support '>'"},
+		{"game:xbox", 0, "unknown game 'xbox'"},
+		{"is=promo", 0, "is only supports ':'"},
+		{"is:shiny", 0, "unknown property 'shiny'"},
+		{"t:elf (c:g or foo:bar)", 14, "unknown keyword 'foo'"},
+	} {
+		node, err := Parse(c.query)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", c.query, err)
+			continue
+		}
+		_, _, err = Compile(node, nil)
+		var queryErr *Error
+		if !errors.As(err, &queryErr) {
+			t.Errorf("Compile(%q) = %v, want a query error", c.query, err)
+			continue
+		}
+		if queryErr.Pos != c.pos || !strings.Contains(queryErr.Message, c.message) {
+			t.Errorf("Compile(%q) = %q at %d, want %q at %d", c.query, queryErr.Message, queryErr.Pos, c.message, c.pos)
+		}
+	}
+}
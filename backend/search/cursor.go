@@ -0,0 +1,52 @@
+package search
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+
+	"github.com/quehorrifico/mana-tomb/backend/db"
+)
+
+// ErrCursorMismatch is returned by DecodeCursor for a cursor issued for another query or order.
+var ErrCursorMismatch = errors.New("cursor belongs to a different query or order")
+
+// Cursor marks the last card of a page: its sort value (nil if it had none), name and ID. Clients
+// only see it encoded, as an opaque string.
+type Cursor struct {
+	Value *string `json:"v,omitempty"`
+	Name  string  `json:"n"`
+	ID    string  `json:"i"`
+	Key   string  `json:"k"` // fingerprint of the query and order the cursor was issued for
+}
+
+// Fingerprint identifies a query and order, so a cursor cannot be replayed against another.
+func Fingerprint(query string, s Sort) string {
+	sum := sha256.Sum256([]byte(query + "\x00" + s.Field + "\x00" + s.Dir()))
+	return hex.EncodeToString(sum[:8])
+}
+
+// Encode returns the opaque form of the cursor.
+func (c Cursor) Encode() string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// DecodeCursor parses a cursor returned by Encode and checks it was issued for the query and
+// order with fingerprint key.
+func DecodeCursor(s, key string) (*Cursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, errors.New("malformed cursor")
+	}
+	var c Cursor
+	if err := json.Unmarshal(b, &c); err != nil || !db.UUIDPattern.MatchString(c.ID) {
+		return nil, errors.New("malformed cursor")
+	}
+	if c.Key != key {
+		return nil, ErrCursorMismatch
+	}
+	return &c, nil
+}
@@ -0,0 +1,102 @@
+package search
+
+import (
+	"encoding/base64"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const (
+	testID      = "0c3b4d9a-7e1f-4d2a-9b6c-5f8e2a1d3c4b"
+	otherTestID = "0c3b4d9a-7e1f-4d2a-9b6c-5f8e2a1d3c4c"
+)
+
+func strPtr(s string) *string { return &s }
+
+func TestCursorRoundTrip(t *testing.T) {
+	key := Fingerprint("t:creature", Sort{Field: "usd", Desc: true})
+	for _, c := range []Cursor{
+		{Value: strPtr("12.50"), Name: "Lightning Bolt", ID: testID, Key: key},
+		{Value: nil, Name: "Lightning Bolt", ID: testID, Key: key},
+		{Value: strPtr(""), Name: "", ID: testID, Key: key},
+		{Value: strPtr("2024-06-14"), Name: `Jötun Grunt "the" \ Fire // Ice`, ID: testID, Key: key},
+	} {
+		encoded := c.Encode()
+		if strings.ContainsAny(encoded, "+/=") {
+			t.Errorf("cursor %q is not URL-safe", encoded)
+		}
+		decoded, err := DecodeCursor(encoded, key)
+		if err != nil {
+			t.Errorf("DecodeCursor(%+v): %v", c, err)
+			continue
+		}
+		if !reflect.DeepEqual(*decoded, c) {
+			t.Errorf("cursor round-tripped to %+v, want %+v", *decoded, c)
+		}
+	}
+}
+
+func TestDecodeCursorRejectsTampering(t *testing.T) {
+	key := Fingerprint("bolt", Sort{Field: "name"})
+	valid := Cursor{Value: strPtr("Bolt"), Name: "Bolt", ID: testID, Key: key}.Encode()
+	encode := func(s string) string { return base64.RawURLEncoding.EncodeToString([]byte(s)) }
+
+	for name, cursor := range map[string]string{
+		"empty":              "",
+		"not base64":         "not a cursor!",
+		"truncated":          valid[:len(valid)-3],
+		"extra bytes":        valid + "AAAA",
+		"not JSON":           encode("Bolt," + testID),
+		"JSON array":         encode(`["Bolt","` + testID + `"]`),
+		"wrong value type":   encode(`{"v":12,"n":"Bolt","i":"` + testID + `","k":"` + key + `"}`),
+		"no ID":              encode(`{"n":"Bolt","k":"` + key + `"}`),
+		"malformed ID":       encode(`{"n":"Bolt","i":"42","k":"` + key + `"}`),
+		"SQL in the ID":      encode(`{"n":"Bolt","i":"` + testID + `'; DROP TABLE oracle_cards; --","k":"` + key + `"}`),
+		"ID with whitespace": encode(`{"n":"Bolt","i":" ` + testID + `","k":"` + key + `"}`),
+	} {
+		c, err := DecodeCursor(cursor, key)
+		if err == nil {
+			t.Errorf("%s: DecodeCursor(%q) = %+v, want an error", name, cursor, c)
+			continue
+		}
+		if errors.Is(err, ErrCursorMismatch) {
+			t.Errorf("%s: DecodeCursor(%q) reported a mismatch, want a malformed cursor", name, cursor)
+		}
+	}
+}
+
+func TestDecodeCursorMismatch(t *testing.T) {
+	sort := Sort{Field: "cmc"}
+	cursor := Cursor{Value: strPtr("3"), Name: "Bolt", ID: testID, Key: Fingerprint("t:instant", sort)}.Encode()
+
+	for name, key := range map[string]string{
+		"other query":     Fingerprint("t:sorcery", sort),
+		"other order":     Fingerprint("t:instant", Sort{Field: "usd"}),
+		"other direction": Fingerprint("t:instant", Sort{Field: "cmc", Desc: true}),
+		"other mode":      Fingerprint("text:t:instant", sort),
+		"no key":          "",
+	} {
+		if _, err := DecodeCursor(cursor, key); !errors.Is(err, ErrCursorMismatch) {
+			t.Errorf("%s: DecodeCursor returned %v, want ErrCursorMismatch", name, err)
+		}
+	}
+	if _, err := DecodeCursor(cursor, Fingerprint("t:instant", sort)); err != nil {
+		t.Errorf("cursor rejected for its own query and order: %v", err)
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	sort := Sort{Field: "name"}
+	if Fingerprint("bolt", sort) != Fingerprint("bolt", sort) {
+		t.Error("fingerprint of the same query and order changed")
+	}
+	// The separators keep the query from running into the order
+	if Fingerprint("bolt", Sort{Field: "name"}) == Fingerprint("boltname", Sort{Field: ""}) {
+		t.Error("fingerprints of different queries collide")
+	}
+	if got := Fingerprint("bolt", sort); len(got) != 16 {
+		t.Errorf("fingerprint %q is not 16 hex digits", got)
+	}
+}
@@ -0,0 +1,189 @@
+package search
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// textConfig is the text search configuration of oracle_cards.search_vector.
+const textConfig = "'english'"
+
+// SnippetExpr is the rules text of a card shown in full-text search snippets: its oracle text,
+// or the oracle text of each face.
+const SnippetExpr = `COALESCE(NULLIF(oracle_text, ''), (SELECT string_agg(face->>'oracle_text', E'\n') FROM jsonb_array_elements(card_faces) AS face))`
+
+// stopwords are the words the english configuration leaves out of every tsquery, as listed in
+// Postgres' english.stop. A text search made only of these would match nothing.
+var stopwords = map[string]bool{}
+
+func init() {
+	for _, w := range strings.Fields(`i me my myself we our ours ourselves you your yours yourself
+		yourselves he him his himself she her hers herself it its itself they them their theirs
+		themselves what which who whom this that these those am is are was were be been being have
+		has had having do does did doing a an the and but if or because as until while of at by for
+		with about against between into through during before after above below to from up down in
+		out on off over under again further then once here there when where why how all any both
+		each few more most other some such no nor not only own same so than too very s t can will
+		just don should now`) {
+		stopwords[w] = true
+	}
+}
+
+// snippetOptions configure ts_headline: up to two fragments with the matches wrapped in <mark>.
+const snippetOptions = `'StartSel=<mark>, StopSel=</mark>, MaxFragments=2, MaxWords=25, MinWords=8, FragmentDelimiter=" … "'`
+
+// FullText is a query compiled for full-text search over oracle_cards.search_vector.
+type FullText struct {
+	TSQuery string // SQL expression of type tsquery
+	Filter  string // SQL condition from the query's keyword terms; "TRUE" if there are none
+}
+
+// Snippet returns an SQL expression highlighting the matches of the query in the card's rules
+// text, given the name tsquery was selected as.
+func Snippet(tsquery string) string {
+	return `ts_headline(` + textConfig + `, ` + SnippetExpr + `, ` + tsquery + `, ` + snippetOptions + `)`
+}
+
+// CompileFullText compiles a parsed query for full-text search. Its words and "quoted phrases"
+// make up the text search, combined with "or", "-" and parentheses like any other term, and a
+// word ending in "*" matches any word starting with it. Keyword terms such as t:creature, and
+// exact names such as !"Lightning Bolt", filter the results as they would in an ordinary search,
+// but may only be combined with the text search by "and", so a group may not mix words and
+// keywords.
+func CompileFullText(node Node, args []interface{}) (FullText, []interface{}, error) {
+	nodes := []Node{node}
+	if and, ok := node.(*And); ok {
+		nodes = and.Nodes
+	}
+
+	c := &compiler{args: args}
+	var text, filters []string
+	for _, n := range nodes {
+		hasWords, keyword := inspect(n)
+		if hasWords && keyword != nil {
+			return FullText{}, nil, &Error{Pos: keyword.Pos, Token: keyword.String(),
+				Message: "keyword terms and exact names can only be combined with the text search by 'and'"}
+		}
+		if !hasWords {
+			filter, err := c.compile(n)
+			if err != nil {
+				return FullText{}, nil, err
+			}
+			filters = append(filters, filter)
+			continue
+		}
+		tsquery, err := c.tsquery(n)
+		if err != nil {
+			return FullText{}, nil, err
+		}
+		text = append(text, tsquery)
+	}
+	if len(text) == 0 {
+		return FullText{}, nil, &Error{Pos: 0, Message: "a text search needs at least one word or phrase"}
+	}
+	if !searchable(node) {
+		return FullText{}, nil, &Error{Pos: 0, Message: "a text search needs a word other than common words such as 'the' or 'of'"}
+	}
+
+	ft := FullText{TSQuery: strings.Join(text, " && "), Filter: "TRUE"}
+	if len(filters) > 0 {
+		ft.Filter = "(" + strings.Join(filters, " AND ") + ")"
+	}
+	return ft, c.args, nil
+}
+
+// inspect reports whether n contains words for the text search, and the first keyword term or
+// exact name in it.
+func inspect(n Node) (hasWords bool, keyword *Term) {
+	switch n := n.(type) {
+	case *And:
+		return inspectAll(n.Nodes)
+	case *Or:
+		return inspectAll(n.Nodes)
+	case *Not:
+		return inspect(n.Node)
+	case *Term:
+		if isText(n) {
+			return true, nil
+		}
+		return false, n
+	}
+	return false, nil
+}
+
+// isText reports whether term is part of the text search rather than a filter.
+func isText(term *Term) bool {
+	return term.Key == "" && !term.Exact
+}
+
+// searchable reports whether n has a text search word that is not a stopword. Postgres drops
+// stopwords from a tsquery, so the text search of a query without one would be empty.
+func searchable(n Node) bool {
+	switch n := n.(type) {
+	case *And:
+		return slices.ContainsFunc(n.Nodes, searchable)
+	case *Or:
+		return slices.ContainsFunc(n.Nodes, searchable)
+	case *Not:
+		return searchable(n.Node)
+	case *Term:
+		if !isText(n) {
+			return false
+		}
+		words := strings.FieldsFunc(strings.ToLower(n.Value), func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) })
+		return slices.ContainsFunc(words, func(w string) bool { return !stopwords[w] })
+	}
+	return false
+}
+
+func inspectAll(nodes []Node) (hasWords bool, keyword *Term) {
+	for _, n := range nodes {
+		w, k := inspect(n)
+		hasWords = hasWords || w
+		if keyword == nil {
+			keyword = k
+		}
+	}
+	return hasWords, keyword
+}
+
+// tsquery compiles a keyword-free node to a tsquery expression.
+func (c *compiler) tsquery(node Node) (string, error) {
+	switch n := node.(type) {
+	case *And, *Or:
+		nodes, sep := []Node(nil), " && "
+		if and, ok := n.(*And); ok {
+			nodes = and.Nodes
+		} else {
+			nodes, sep = n.(*Or).Nodes, " || "
+		}
+		parts := make([]string, len(nodes))
+		for i, child := range nodes {
+			part, err := c.tsquery(child)
+			if err != nil {
+				return "", err
+			}
+			parts[i] = part
+		}
+		return "(" + strings.Join(parts, sep) + ")", nil
+	case *Not:
+		inner, err := c.tsquery(n.Node)
+		if err != nil {
+			return "", err
+		}
+		return "(!! " + inner + ")", nil
+	case *Term:
+		if !n.Quoted && strings.HasSuffix(n.Value, "*") {
+			word := strings.TrimSuffix(n.Value, "*")
+			if word == "" || strings.IndexFunc(word, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) }) >= 0 {
+				return "", &Error{Pos: n.Pos, Token: n.Value, Message: "a prefix search needs letters or digits before '*'"}
+			}
+			// Only letters and digits reach to_tsquery, so the prefix cannot inject tsquery syntax
+			return "to_tsquery(" + textConfig + ", " + c.arg(word+":*") + ")", nil
+		}
+		// Words and quoted phrases alike must appear in order, as phrases do on Scryfall
+		return "phraseto_tsquery(" + textConfig + ", " + c.arg(n.Value) + ")", nil
+	}
+	return "", &Error{Pos: 0, Message: "unsupported text search term"}
+}
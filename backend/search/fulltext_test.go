@@ -0,0 +1,78 @@
+package search
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCompileFullText(t *testing.T) {
+	for _, c := range []struct {
+		query   string
+		tsquery string
+		filter  string
+		args    []interface{}
+	}{
+		{"flying", `phraseto_tsquery('english', $1)`, `TRUE`, []interface{}{"flying"}},
+		{`"draw a card" or scry*`, `(phraseto_tsquery('english', $1) || to_tsquery('english', $2))`, `TRUE`,
+			[]interface{}{"draw a card", "scry:*"}},
+		{"-the flying", `(!! phraseto_tsquery('english', $1)) && phraseto_tsquery('english', $2)`, `TRUE`,
+			[]interface{}{"the", "flying"}},
+		{"flying t:creature", `phraseto_tsquery('english', $1)`, `(type_line ILIKE $2)`, []interface{}{"flying", "%creature%"}},
+		// An exact name filters the results like any keyword rather than joining the text search
+		{`!"Fire // Ice" draw`, `phraseto_tsquery('english', $2)`,
+			`((lower(name) = lower($1) OR EXISTS (SELECT 1 FROM jsonb_array_elements(card_faces) AS face WHERE lower(face->>'name') = lower($1))))`,
+			[]interface{}{"Fire // Ice", "draw"}},
+	} {
+		node, err := Parse(c.query)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", c.query, err)
+			continue
+		}
+		ft, args, err := CompileFullText(node, nil)
+		if err != nil {
+			t.Errorf("CompileFullText(%q): %v", c.query, err)
+			continue
+		}
+		if ft.TSQuery != c.tsquery || ft.Filter != c.filter {
+			t.Errorf("CompileFullText(%q) =\n\t%s\n\t%s\nwant\n\t%s\n\t%s", c.query, ft.TSQuery, ft.Filter, c.tsquery, c.filter)
+		}
+		if !reflect.DeepEqual(args, c.args) {
+			t.Errorf("CompileFullText(%q) args = %#v, want %#v", c.query, args, c.args)
+		}
+	}
+}
+
+func TestCompileFullTextErrors(t *testing.T) {
+	for _, c := range []struct {
+		query   string
+		pos     int
+		message string
+	}{
+		{"t:creature", 0, "needs at least one word"},
+		{`!"Lightning Bolt"`, 0, "needs at least one word"},
+		{"flying or t:creature", 10, "can only be combined with the text search by 'and'"},
+		{`(draw or !Opt)`, 9, "can only be combined with the text search by 'and'"},
+		{"the", 0, "common words"},
+		{`"to the" or -of`, 0, "common words"},
+		{"don't t:creature", 0, "common words"},
+		{"the*", 0, "common words"},
+		{"fly-*", 0, "prefix search needs letters or digits"},
+	} {
+		node, err := Parse(c.query)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", c.query, err)
+			continue
+		}
+		_, _, err = CompileFullText(node, nil)
+		var queryErr *Error
+		if !errors.As(err, &queryErr) {
+			t.Errorf("CompileFullText(%q) = %v, want a query error", c.query, err)
+			continue
+		}
+		if queryErr.Pos != c.pos || !strings.Contains(queryErr.Message, c.message) {
+			t.Errorf("CompileFullText(%q) = %q at %d, want %q at %d", c.query, queryErr.Message, queryErr.Pos, c.message, c.pos)
+		}
+	}
+}
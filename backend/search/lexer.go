@@ -0,0 +1,154 @@
+package search
+
+import (
+	"strings"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenLParen
+	tokenRParen
+	tokenOr
+	tokenAnd
+	tokenNot
+	tokenTerm
+)
+
+// token is one lexical element of a query. Terms carry their parsed parts so the parser never
+// has to look inside them.
+type token struct {
+	kind tokenKind
+	pos  int    // byte offset in the query
+	text string // the source text of the token
+	term *Term
+}
+
+// operators are the comparison operators a keyword may be followed by, longest first so "<=" is
+// not read as "<".
+var operators = []string{"!=", "<=", ">=", ":", "=", "<", ">"}
+
+// lex splits a query into tokens.
+func lex(query string) ([]token, error) {
+	var tokens []token
+	i := 0
+	for i < len(query) {
+		c := query[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '(':
+			tokens = append(tokens, token{kind: tokenLParen, pos: i, text: "("})
+			i++
+		case c == ')':
+			tokens = append(tokens, token{kind: tokenRParen, pos: i, text: ")"})
+			i++
+		case c == '-':
+			// A "-" on its own would otherwise be read as a name containing "-"
+			if i+1 == len(query) || isSpace(query[i+1]) {
+				return nil, &Error{Pos: i, Token: "-", Message: "'-' must be directly followed by the term it negates"}
+			}
+			tokens = append(tokens, token{kind: tokenNot, pos: i, text: "-"})
+			i++
+		default:
+			tok, next, err := lexTerm(query, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, tok)
+			i = next
+		}
+	}
+	return append(tokens, token{kind: tokenEOF, pos: len(query)}), nil
+}
+
+// lexTerm reads the term starting at start: a bare or quoted word, an exact name (!name or
+// !"name"), or a keyword, operator and value such as o:"draw a card" or cmc<=3.
+func lexTerm(query string, start int) (token, int, error) {
+	i := start
+	exact := false
+	if query[i] == '!' {
+		exact = true
+		i++
+		if i == len(query) || isSpace(query[i]) || query[i] == ')' {
+			return token{}, 0, &Error{Pos: start, Token: "!", Message: "'!' must be followed by a card name"}
+		}
+	}
+
+	// A keyword is a run of letters directly followed by an operator
+	if !exact {
+		k := i
+		for k < len(query) && (isLetter(query[k]) || query[k] == '_') {
+			k++
+		}
+		if k > i {
+			for _, op := range operators {
+				if strings.HasPrefix(query[k:], op) {
+					value, quoted, next, err := lexValue(query, k+len(op))
+					if err != nil {
+						return token{}, 0, err
+					}
+					if value == "" && !quoted {
+						return token{}, 0, &Error{Pos: start, Token: query[start:next], Message: "'" + query[i:k] + op + "' needs a value"}
+					}
+					term := &Term{Key: strings.ToLower(query[i:k]), Op: op, Value: value, Quoted: quoted, Pos: start}
+					return token{kind: tokenTerm, pos: start, text: query[start:next], term: term}, next, nil
+				}
+			}
+		}
+	}
+
+	value, quoted, next, err := lexValue(query, i)
+	if err != nil {
+		return token{}, 0, err
+	}
+	text := query[start:next]
+	if !quoted && !exact {
+		switch strings.ToLower(value) {
+		case "or":
+			return token{kind: tokenOr, pos: start, text: text}, next, nil
+		case "and":
+			return token{kind: tokenAnd, pos: start, text: text}, next, nil
+		case "not":
+			return token{kind: tokenNot, pos: start, text: text}, next, nil
+		}
+	}
+	term := &Term{Value: value, Quoted: quoted, Exact: exact, Pos: start}
+	return token{kind: tokenTerm, pos: start, text: text, term: term}, next, nil
+}
+
+// lexValue reads a quoted string, or a bare word ending at whitespace or a closing parenthesis.
+// Inside quotes, \" and \\ escape a quote and a backslash.
+func lexValue(query string, start int) (value string, quoted bool, next int, err error) {
+	if start < len(query) && query[start] == '"' {
+		var b strings.Builder
+		for i := start + 1; i < len(query); i++ {
+			switch c := query[i]; {
+			case c == '\\' && i+1 < len(query) && (query[i+1] == '"' || query[i+1] == '\\'):
+				b.WriteByte(query[i+1])
+				i++
+			case c == '"':
+				return b.String(), true, i + 1, nil
+			default:
+				b.WriteByte(c)
+			}
+		}
+		return "", false, 0, &Error{Pos: start, Token: query[start:], Message: "unterminated quoted string"}
+	}
+
+	i := start
+	for i < len(query) && !isSpace(query[i]) && query[i] != ')' && query[i] != '(' {
+		i++
+	}
+	return query[start:i], false, i, nil
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+func isLetter(c byte) bool {
+	return c < 0x80 && unicode.IsLetter(rune(c))
+}
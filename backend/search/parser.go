@@ -0,0 +1,215 @@
+// Package search parses Scryfall-style card queries such as
+//
+//	t:creature c:g cmc<=3 o:"draw a card" f:commander (r:rare or r:mythic) -t:legendary
+//
+// into an AST and compiles it to a parameterized SQL condition over the oracle_cards table.
+// Terms next to each other must all match; "or" separates alternatives, "-" or "not" negates a
+// term or parenthesized group, and "and" may be written out but is implied.
+package search
+
+import (
+	"fmt"
+	"strings"
+)
+
+// maxQueryLength bounds the size of a query, and with it the size of the SQL it compiles to.
+const maxQueryLength = 1000
+
+// Error is a syntax or semantic error in a query, located at byte offset Pos.
+type Error struct {
+	Pos     int    `json:"position"`
+	Token   string `json:"token,omitempty"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	if e.Token != "" {
+		return fmt.Sprintf("%s at position %d (%q)", e.Message, e.Pos, e.Token)
+	}
+	return fmt.Sprintf("%s at position %d", e.Message, e.Pos)
+}
+
+// Node is a node of a parsed query: *And, *Or, *Not or *Term.
+type Node interface {
+	String() string
+}
+
+// And matches cards matching every one of Nodes.
+type And struct {
+	Nodes []Node
+}
+
+// Or matches cards matching any of Nodes.
+type Or struct {
+	Nodes []Node
+}
+
+// Not matches cards not matching Node.
+type Not struct {
+	Node Node
+}
+
+// Term is a single condition. A term without a Key matches card names: by substring, or the
+// whole name if Exact (written !name).
+type Term struct {
+	Key    string // lower-cased keyword, e.g. "t" or "cmc"; empty for a name
+	Op     string // one of ":", "=", "!=", "<", "<=", ">", ">="; empty for a name
+	Value  string
+	Quoted bool
+	Exact  bool
+	Pos    int
+}
+
+func (n *And) String() string { return "(" + joinNodes(n.Nodes, " ") + ")" }
+func (n *Or) String() string  { return "(" + joinNodes(n.Nodes, " or ") + ")" }
+func (n *Not) String() string { return "-" + n.Node.String() }
+
+func (t *Term) String() string {
+	value := t.Value
+	if t.Quoted || strings.ContainsAny(value, " ()") {
+		value = fmt.Sprintf("%q", value)
+	}
+	if t.Exact {
+		return "!" + value
+	}
+	return t.Key + t.Op + value
+}
+
+func joinNodes(nodes []Node, sep string) string {
+	parts := make([]string, len(nodes))
+	for i, n := range nodes {
+		parts[i] = n.String()
+	}
+	return strings.Join(parts, sep)
+}
+
+// Parse parses a query into its AST.
+func Parse(query string) (Node, error) {
+	if len(query) > maxQueryLength {
+		return nil, &Error{Pos: maxQueryLength, Message: fmt.Sprintf("query is longer than %d characters", maxQueryLength)}
+	}
+	if strings.TrimSpace(query) == "" {
+		return nil, &Error{Pos: 0, Message: "query is empty"}
+	}
+	tokens, err := lex(query)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+	node, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokenEOF {
+		if tok.kind == tokenRParen {
+			return nil, &Error{Pos: tok.pos, Token: tok.text, Message: "unmatched ')'"}
+		}
+		return nil, &Error{Pos: tok.pos, Token: tok.text, Message: "unexpected " + describe(tok)}
+	}
+	return node, nil
+}
+
+// parser is a recursive descent parser over the grammar
+//
+//	or    = and { "or" and }
+//	and   = unary { ["and"] unary }
+//	unary = ("-" | "not") unary | "(" or ")" | term
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token { return p.tokens[p.pos] }
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokenEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *parser) parseOr() (Node, error) {
+	first, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	nodes := []Node{first}
+	for p.peek().kind == tokenOr {
+		p.next()
+		node, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, node)
+	}
+	if len(nodes) == 1 {
+		return first, nil
+	}
+	return &Or{Nodes: nodes}, nil
+}
+
+func (p *parser) parseAnd() (Node, error) {
+	first, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	nodes := []Node{first}
+	for {
+		tok := p.peek()
+		if tok.kind == tokenAnd {
+			p.next()
+		} else if tok.kind != tokenTerm && tok.kind != tokenNot && tok.kind != tokenLParen {
+			break
+		}
+		node, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, node)
+	}
+	if len(nodes) == 1 {
+		return first, nil
+	}
+	return &And{Nodes: nodes}, nil
+}
+
+func (p *parser) parseUnary() (Node, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokenNot:
+		node, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &Not{Node: node}, nil
+	case tokenLParen:
+		if p.peek().kind == tokenRParen {
+			return nil, &Error{Pos: tok.pos, Token: "()", Message: "empty parentheses"}
+		}
+		node, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != tokenRParen {
+			return nil, &Error{Pos: tok.pos, Token: "(", Message: "unmatched '('"}
+		}
+		return node, nil
+	case tokenTerm:
+		return tok.term, nil
+	default:
+		return nil, &Error{Pos: tok.pos, Token: tok.text, Message: "expected a search term but found " + describe(tok)}
+	}
+}
+
+func describe(tok token) string {
+	switch tok.kind {
+	case tokenEOF:
+		return "end of query"
+	case tokenOr, tokenAnd:
+		return "'" + strings.ToLower(tok.text) + "'"
+	default:
+		return "'" + tok.text + "'"
+	}
+}
@@ -0,0 +1,106 @@
+package search
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	for _, c := range []struct {
+		query string
+		want  string // String() of the parsed query
+	}{
+		{"bolt", "bolt"},
+		{"t:creature", "t:creature"},
+		{"T:Creature", "t:Creature"},
+		{"cmc<=3", "cmc<=3"},
+		{"cmc>=3 cmc!=4", "(cmc>=3 cmc!=4)"},
+		{`o:"draw a card"`, `o:"draw a card"`},
+		{`o:"say \"hi\" \\ bye"`, `o:"say \"hi\" \\ bye"`},
+		{`!"Lightning Bolt"`, `!"Lightning Bolt"`},
+		{"!Opt", "!Opt"},
+		{"t:creature c:g", "(t:creature c:g)"},
+		{"t:creature and c:g", "(t:creature c:g)"},
+		{"r:rare or r:mythic", "(r:rare or r:mythic)"},
+		{"a b or c d", "((a b) or (c d))"},
+		{"t:creature (r:rare OR r:mythic)", "(t:creature (r:rare or r:mythic))"},
+		{"-t:legendary", "-t:legendary"},
+		{"not t:legendary", "-t:legendary"},
+		{"--x", "--x"},
+		{"-(a or b)", "-(a or b)"},
+		{"((a))", "a"},
+		{"Mind-Sculptor", "Mind-Sculptor"},
+		{"x-", "x-"},
+		{`"or"`, `"or"`},
+		{"name:or", "name:or"},
+		{`o:""`, `o:""`},
+	} {
+		node, err := Parse(c.query)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", c.query, err)
+			continue
+		}
+		if got := node.String(); got != c.want {
+			t.Errorf("Parse(%q) = %s, want %s", c.query, got, c.want)
+		}
+	}
+}
+
+func TestParseTerms(t *testing.T) {
+	node, err := Parse(`!"Fire // Ice" o:"~ deals" cmc>=2`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	and, ok := node.(*And)
+	if !ok || len(and.Nodes) != 3 {
+		t.Fatalf("parsed %s, want three terms", node)
+	}
+	want := []Term{
+		{Value: "Fire // Ice", Quoted: true, Exact: true, Pos: 0},
+		{Key: "o", Op: ":", Value: "~ deals", Quoted: true, Pos: 15},
+		{Key: "cmc", Op: ">=", Value: "2", Pos: 27},
+	}
+	for i, n := range and.Nodes {
+		if term, ok := n.(*Term); !ok || *term != want[i] {
+			t.Errorf("term %d = %+v, want %+v", i, n, want[i])
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, c := range []struct {
+		query   string
+		pos     int
+		message string
+	}{
+		{"", 0, "query is empty"},
+		{"   ", 0, "query is empty"},
+		{strings.Repeat("a", maxQueryLength+1), maxQueryLength, "longer than"},
+		{"- x", 0, "'-' must be directly followed"},
+		{"x -", 2, "'-' must be directly followed"},
+		{"!", 0, "'!' must be followed by a card name"},
+		{"! x", 0, "'!' must be followed by a card name"},
+		{"t:", 0, "'t:' needs a value"},
+		{"x cmc>=", 2, "'cmc>=' needs a value"},
+		{`o:"draw`, 2, "unterminated quoted string"},
+		{"(t:creature", 0, "unmatched '('"},
+		{"t:creature)", 10, "unmatched ')'"},
+		{"()", 0, "empty parentheses"},
+		{"or x", 0, "expected a search term but found 'or'"},
+		{"x or", 4, "expected a search term but found end of query"},
+		{"x and or y", 6, "expected a search term but found 'or'"},
+		{"-)", 1, "expected a search term but found ')'"},
+		{"not", 3, "expected a search term but found end of query"},
+	} {
+		_, err := Parse(c.query)
+		var queryErr *Error
+		if !errors.As(err, &queryErr) {
+			t.Errorf("Parse(%q) = %v, want a query error", c.query, err)
+			continue
+		}
+		if queryErr.Pos != c.pos || !strings.Contains(queryErr.Message, c.message) {
+			t.Errorf("Parse(%q) = %q at %d, want %q at %d", c.query, queryErr.Message, queryErr.Pos, c.message, c.pos)
+		}
+	}
+}
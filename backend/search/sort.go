@@ -0,0 +1,103 @@
+package search
+
+import (
+	"fmt"
+	"strings"
+)
+
+// sortField is a column results can be ordered by.
+type sortField struct {
+	expr       string // SQL expression over oracle_cards
+	sqlType    string // type cursor values are cast back to
+	defaultDir string
+}
+
+// sortFields are the orders search results support. Cards without a value (no EDHREC rank, no
+// USD price, ...) always come last, whatever the direction.
+var sortFields = map[string]sortField{
+	"name":        {expr: `name`, sqlType: "text", defaultDir: "asc"},
+	"cmc":         {expr: `cmc`, sqlType: "numeric", defaultDir: "asc"},
+	"released_at": {expr: `released_at`, sqlType: "date", defaultDir: "desc"},
+	"edhrec_rank": {expr: `edhrec_rank`, sqlType: "integer", defaultDir: "asc"},
+	"rarity":      {expr: rarityExpr, sqlType: "integer", defaultDir: "desc"},
+	"usd":         {expr: `NULLIF(prices->>'usd', '')::numeric`, sqlType: "numeric", defaultDir: "desc"},
+
+	// The ts_rank of a full-text search, which its query selects as rank
+	"relevance": {expr: `rank`, sqlType: "real", defaultDir: "desc"},
+}
+
+// Sort is the order of a result set. Ties are broken by name and then ID, so the order is total
+// and keyset pagination never skips or repeats a card.
+type Sort struct {
+	Field string
+	Desc  bool
+}
+
+// ParseSort validates an order and direction such as "usd" and "desc". An empty order sorts by
+// name, and an empty direction uses the field's natural one (cheapest first for cmc, newest first
+// for released_at, most expensive first for usd, ...). Only full-text searches can be ordered by
+// relevance.
+func ParseSort(order, dir string) (Sort, error) {
+	if order == "" {
+		order = "name"
+	}
+	field, ok := sortFields[order]
+	if !ok {
+		return Sort{}, fmt.Errorf("unknown order %q; use one of name, cmc, released_at, edhrec_rank, rarity, usd or relevance", order)
+	}
+	if dir == "" {
+		dir = field.defaultDir
+	}
+	if dir != "asc" && dir != "desc" {
+		return Sort{}, fmt.Errorf("unknown direction %q; use asc or desc", dir)
+	}
+	return Sort{Field: order, Desc: dir == "desc"}, nil
+}
+
+// Dir returns "asc" or "desc".
+func (s Sort) Dir() string {
+	if s.Desc {
+		return "desc"
+	}
+	return "asc"
+}
+
+// Expr is the SQL expression the results are ordered by. Select it as text to build a cursor
+// from the last row of a page.
+func (s Sort) Expr() string {
+	return sortFields[s.Field].expr
+}
+
+// OrderBy returns the ORDER BY list for s.
+func (s Sort) OrderBy() string {
+	expr := s.Expr()
+	if s.Field == "name" {
+		return fmt.Sprintf(`name %s, id`, strings.ToUpper(s.Dir()))
+	}
+	return fmt.Sprintf(`(%s) IS NULL, %s %s, name, id`, expr, expr, strings.ToUpper(s.Dir()))
+}
+
+// After returns an SQL condition selecting the rows that sort after the cursor, with its values
+// passed as parameters appended to args.
+func (s Sort) After(cursor *Cursor, args []interface{}) (string, []interface{}) {
+	c := &compiler{args: args}
+	cmp := ">"
+	if s.Desc {
+		cmp = "<"
+	}
+
+	if s.Field == "name" {
+		name, id := c.arg(cursor.Name), c.arg(cursor.ID)
+		return fmt.Sprintf(`(name %s %s OR (name = %s AND id > %s::uuid))`, cmp, name, name, id), c.args
+	}
+
+	expr := s.Expr()
+	name, id := c.arg(cursor.Name), c.arg(cursor.ID)
+	tieBreak := fmt.Sprintf(`(name, id) > (%s, %s::uuid)`, name, id)
+	if cursor.Value == nil {
+		// Past the end of the cards with a value: only cards without one remain
+		return fmt.Sprintf(`((%s) IS NULL AND %s)`, expr, tieBreak), c.args
+	}
+	value := c.arg(*cursor.Value) + "::" + sortFields[s.Field].sqlType
+	return fmt.Sprintf(`((%s) IS NULL OR %s %s %s OR (%s = %s AND %s))`, expr, expr, cmp, value, expr, value, tieBreak), c.args
+}
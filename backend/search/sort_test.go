@@ -0,0 +1,177 @@
+package search
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseSort(t *testing.T) {
+	for _, c := range []struct {
+		order, dir string
+		want       Sort
+	}{
+		{"", "", Sort{Field: "name"}},
+		{"name", "desc", Sort{Field: "name", Desc: true}},
+		{"cmc", "", Sort{Field: "cmc"}},
+		{"released_at", "", Sort{Field: "released_at", Desc: true}},
+		{"usd", "", Sort{Field: "usd", Desc: true}},
+		{"usd", "asc", Sort{Field: "usd"}},
+		{"edhrec_rank", "", Sort{Field: "edhrec_rank"}},
+		{"rarity", "", Sort{Field: "rarity", Desc: true}},
+		{"relevance", "", Sort{Field: "relevance", Desc: true}},
+	} {
+		got, err := ParseSort(c.order, c.dir)
+		if err != nil {
+			t.Errorf("ParseSort(%q, %q): %v", c.order, c.dir, err)
+			continue
+		}
+		if got != c.want {
+			t.Errorf("ParseSort(%q, %q) = %+v, want %+v", c.order, c.dir, got, c.want)
+		}
+	}
+	for _, c := range [][2]string{{"power", ""}, {"NAME", ""}, {"name", "up"}, {"cmc", "DESC"}} {
+		if _, err := ParseSort(c[0], c[1]); err == nil {
+			t.Errorf("ParseSort(%q, %q) succeeded, want an error", c[0], c[1])
+		}
+	}
+}
+
+func TestOrderByBreaksTiesByNameAndID(t *testing.T) {
+	for _, c := range []struct {
+		sort Sort
+		want string
+	}{
+		{Sort{Field: "name"}, `name ASC, id`},
+		{Sort{Field: "name", Desc: true}, `name DESC, id`},
+		{Sort{Field: "cmc"}, `(cmc) IS NULL, cmc ASC, name, id`},
+		{Sort{Field: "usd", Desc: true}, `(NULLIF(prices->>'usd', '')::numeric) IS NULL, NULLIF(prices->>'usd', '')::numeric DESC, name, id`},
+	} {
+		if got := c.sort.OrderBy(); got != c.want {
+			t.Errorf("%+v.OrderBy() = %s, want %s", c.sort, got, c.want)
+		}
+	}
+}
+
+func TestAfter(t *testing.T) {
+	for _, c := range []struct {
+		sort   Sort
+		cursor Cursor
+		want   string
+		args   []interface{}
+	}{
+		{Sort{Field: "name"}, Cursor{Name: "Bolt", ID: testID},
+			`(name > $2 OR (name = $2 AND id > $3::uuid))`, []interface{}{"prev", "Bolt", testID}},
+		{Sort{Field: "name", Desc: true}, Cursor{Name: "Bolt", ID: testID},
+			`(name < $2 OR (name = $2 AND id > $3::uuid))`, []interface{}{"prev", "Bolt", testID}},
+		{Sort{Field: "cmc"}, Cursor{Value: strPtr("3"), Name: "Bolt", ID: testID},
+			`((cmc) IS NULL OR cmc > $4::numeric OR (cmc = $4::numeric AND (name, id) > ($2, $3::uuid)))`, []interface{}{"prev", "Bolt", testID, "3"}},
+		{Sort{Field: "cmc", Desc: true}, Cursor{Value: strPtr("3"), Name: "Bolt", ID: testID},
+			`((cmc) IS NULL OR cmc < $4::numeric OR (cmc = $4::numeric AND (name, id) > ($2, $3::uuid)))`, []interface{}{"prev", "Bolt", testID, "3"}},
+		{Sort{Field: "cmc"}, Cursor{Name: "Bolt", ID: testID},
+			`((cmc) IS NULL AND (name, id) > ($2, $3::uuid))`, []interface{}{"prev", "Bolt", testID}},
+	} {
+		got, args := c.sort.After(&c.cursor, []interface{}{"prev"})
+		if got != c.want {
+			t.Errorf("%+v.After(%+v) =\n\t%s\nwant\n\t%s", c.sort, c.cursor, got, c.want)
+		}
+		if !reflect.DeepEqual(args, c.args) {
+			t.Errorf("%+v.After(%+v) args = %#v, want %#v", c.sort, c.cursor, args, c.args)
+		}
+	}
+}
+
+// TestKeysetPagingBreaksTiesByID pages through cards that share sort values and names, a row at a
+// time and in every direction, and checks the pages list every card exactly once in ORDER BY
+// order. It needs Postgres, at TEST_DATABASE_URL, but no tables.
+func TestKeysetPagingBreaksTiesByID(t *testing.T) {
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL not set; skipping database test")
+	}
+	database, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer database.Close()
+
+	// Two pairs of cards with the same name and mana value, told apart only by ID, and cards
+	// without a mana value, which come last
+	cards := `(VALUES
+		('Bolt', '` + otherTestID + `'::uuid, 1::numeric),
+		('Bolt', '` + testID + `'::uuid, 1::numeric),
+		('Shock', '` + testID + `'::uuid, 1::numeric),
+		('Opt', '` + testID + `'::uuid, 1::numeric),
+		('Opt', '` + otherTestID + `'::uuid, 1::numeric),
+		('Anger', '` + testID + `'::uuid, 3::numeric),
+		('Zap', '` + testID + `'::uuid, NULL::numeric),
+		('Zap', '` + otherTestID + `'::uuid, NULL::numeric)
+	) AS oracle_cards (name, id, cmc)`
+
+	for _, sort := range []Sort{{Field: "name"}, {Field: "name", Desc: true}, {Field: "cmc"}, {Field: "cmc", Desc: true}} {
+		want := queryKeys(t, database, `SELECT name, id::text, (`+sort.Expr()+`)::text FROM `+cards+` ORDER BY `+sort.OrderBy(), nil)
+
+		var got []string
+		var cursor *Cursor
+		for page := 0; page <= len(want); page++ {
+			where, args := "TRUE", []interface{}{}
+			if cursor != nil {
+				where, args = sort.After(cursor, args)
+			}
+			rows := queryRows(t, database, `SELECT name, id::text, (`+sort.Expr()+`)::text FROM `+cards+
+				` WHERE `+where+` ORDER BY `+sort.OrderBy()+` LIMIT 1`, args)
+			if len(rows) == 0 {
+				break
+			}
+			last := rows[0]
+			got = append(got, last.name+" "+last.id)
+			cursor = &Cursor{Name: last.name, ID: last.id}
+			if last.value.Valid {
+				cursor.Value = &last.value.String
+			}
+		}
+
+		if strings.Join(got, ", ") != strings.Join(want, ", ") {
+			t.Errorf("paging by %s %s listed\n\t%s\nwant\n\t%s", sort.Field, sort.Dir(), strings.Join(got, ", "), strings.Join(want, ", "))
+		}
+	}
+}
+
+type sortRow struct {
+	name, id string
+	value    sql.NullString
+}
+
+func queryRows(t *testing.T, database *sql.DB, query string, args []interface{}) []sortRow {
+	t.Helper()
+	rows, err := database.Query(query, args...)
+	if err != nil {
+		t.Fatalf("%s: %v", query, err)
+	}
+	defer rows.Close()
+	var result []sortRow
+	for rows.Next() {
+		var r sortRow
+		if err := rows.Scan(&r.name, &r.id, &r.value); err != nil {
+			t.Fatal(err)
+		}
+		result = append(result, r)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return result
+}
+
+// queryKeys returns the rows of query as "name id" strings.
+func queryKeys(t *testing.T, database *sql.DB, query string, args []interface{}) []string {
+	t.Helper()
+	var keys []string
+	for _, r := range queryRows(t, database, query, args) {
+		keys = append(keys, fmt.Sprintf("%s %s", r.name, r.id))
+	}
+	return keys
+}
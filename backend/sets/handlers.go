@@ -0,0 +1,264 @@
+package sets
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/quehorrifico/mana-tomb/backend/cards"
+	"github.com/quehorrifico/mana-tomb/backend/db"
+	"github.com/quehorrifico/mana-tomb/backend/middleware"
+	"github.com/quehorrifico/mana-tomb/backend/models"
+)
+
+var DB *sql.DB
+
+// Default and maximum page sizes of GetSetCards.
+const (
+	defaultPageSize = 60
+	maxPageSize     = 175
+)
+
+// setOrders maps the "order" parameter of ListSets to its ORDER BY column.
+var setOrders = map[string]string{
+	"released_at": "released_at",
+	"name":        "name",
+	"code":        "code",
+	"card_count":  "card_count",
+}
+
+const setColumns = `id, code, COALESCE(mtgo_code, ''), COALESCE(arena_code, ''), COALESCE(tcgplayer_id, 0), name, set_type,
+	COALESCE(to_char(released_at, 'YYYY-MM-DD'), ''), COALESCE(block_code, ''), COALESCE(block, ''), COALESCE(parent_set_code, ''),
+	card_count, COALESCE(printed_size, 0), digital, foil_only, nonfoil_only, COALESCE(scryfall_uri, ''), COALESCE(uri, ''),
+	COALESCE(icon_svg_uri, ''), COALESCE(search_uri, '')`
+
+// List sets, newest first by default, e.g.
+// /sets?type=expansion&q=innistrad&parent=m10&digital=false&released_after=2010-01-01&released_before=2020-12-31&order=name&dir=asc
+func ListSets(w http.ResponseWriter, r *http.Request) {
+	middleware.EnableCORS(w)
+	if r.Method == "OPTIONS" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	query := r.URL.Query()
+	var conditions []string
+	var args []interface{}
+	addCondition := func(format string, value interface{}) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(format, len(args)))
+	}
+
+	if v := query.Get("type"); v != "" {
+		addCondition("set_type = ANY($%d)", pq.Array(strings.Split(v, ",")))
+	}
+	if v := query.Get("q"); v != "" {
+		addCondition("(name ILIKE '%%' || $%[1]d || '%%' OR code = lower($%[1]d))", v)
+	}
+	if v := query.Get("parent"); v != "" {
+		addCondition("parent_set_code = lower($%d)", v)
+	}
+	if v := query.Get("digital"); v != "" {
+		digital, err := strconv.ParseBool(v)
+		if err != nil {
+			http.Error(w, "Invalid 'digital', expected true or false", http.StatusBadRequest)
+			return
+		}
+		addCondition("digital = $%d", digital)
+	}
+	for _, bound := range []struct{ param, op string }{{"released_after", ">="}, {"released_before", "<="}} {
+		param, op := bound.param, bound.op
+		v := query.Get(param)
+		if v == "" {
+			continue
+		}
+		if _, err := time.Parse("2006-01-02", v); err != nil {
+			http.Error(w, fmt.Sprintf("Invalid '%s' date, expected YYYY-MM-DD", param), http.StatusBadRequest)
+			return
+		}
+		addCondition("released_at "+op+" $%d", v)
+	}
+
+	order := "released_at"
+	if v := query.Get("order"); v != "" {
+		column, ok := setOrders[v]
+		if !ok {
+			http.Error(w, "Invalid 'order', expected released_at, name, code or card_count", http.StatusBadRequest)
+			return
+		}
+		order = column
+	}
+	dir := "DESC"
+	switch query.Get("dir") {
+	case "", "desc":
+	case "asc":
+		dir = "ASC"
+	default:
+		http.Error(w, "Invalid 'dir', expected asc or desc", http.StatusBadRequest)
+		return
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+	rows, err := DB.Query(`SELECT `+setColumns+` FROM sets `+where+` ORDER BY `+order+` `+dir+` NULLS LAST, code`, args...)
+	if err != nil {
+		http.Error(w, "Error fetching sets", http.StatusInternalServerError)
+		log.Println("❌ Error fetching sets:", err)
+		return
+	}
+	defer rows.Close()
+
+	sets := []models.Set{}
+	for rows.Next() {
+		set, err := scanSet(rows)
+		if err != nil {
+			http.Error(w, "Error fetching sets", http.StatusInternalServerError)
+			log.Println("❌ Error scanning set:", err)
+			return
+		}
+		sets = append(sets, set)
+	}
+	if err := rows.Err(); err != nil {
+		http.Error(w, "Error fetching sets", http.StatusInternalServerError)
+		log.Println("❌ Error reading sets:", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(sets)
+}
+
+// Get one set by its code, e.g. /sets/mh2
+func GetSet(w http.ResponseWriter, r *http.Request) {
+	middleware.EnableCORS(w)
+	if r.Method == "OPTIONS" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	set, err := scanSet(DB.QueryRow(`SELECT `+setColumns+` FROM sets WHERE code = lower($1)`, r.PathValue("code")))
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "Set not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Error fetching set", http.StatusInternalServerError)
+		log.Println("❌ Error fetching set:", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(set)
+}
+
+// Page through the printings of a set in collector-number order, e.g. /sets/mh2/cards?page=2&page_size=60
+func GetSetCards(w http.ResponseWriter, r *http.Request) {
+	middleware.EnableCORS(w)
+	if r.Method == "OPTIONS" {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	page, err := positiveParam(r, "page", 1, 0)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	pageSize, err := positiveParam(r, "page_size", defaultPageSize, maxPageSize)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var code string
+	var total int
+	err = DB.QueryRow(`
+		SELECT s.code, (SELECT count(*) FROM printings p WHERE p.set = s.code AND p.retired_at IS NULL)
+		FROM sets s
+		WHERE s.code = lower($1);
+	`, r.PathValue("code")).Scan(&code, &total)
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "Set not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Error fetching set cards", http.StatusInternalServerError)
+		log.Println("❌ Error fetching set:", err)
+		return
+	}
+
+	// Collector numbers are text like "12", "12a" or "★3": sort by their leading number first
+	rows, err := DB.Query(`
+		SELECT `+cards.PrintingColumns+`
+		FROM printings
+		WHERE set = $1 AND retired_at IS NULL
+		ORDER BY NULLIF(substring(collector_number FROM '^[0-9]+'), '')::int NULLS LAST, collector_number, lang, id
+		LIMIT $2 OFFSET $3;
+	`, code, pageSize, (page-1)*pageSize)
+	if err != nil {
+		http.Error(w, "Error fetching set cards", http.StatusInternalServerError)
+		log.Println("❌ Error fetching set cards:", err)
+		return
+	}
+	defer rows.Close()
+
+	printings := []cards.PrintingResponse{}
+	for rows.Next() {
+		p, err := cards.ScanPrinting(rows)
+		if err != nil {
+			http.Error(w, "Error fetching set cards", http.StatusInternalServerError)
+			log.Println("❌ Error scanning set card:", err)
+			return
+		}
+		printings = append(printings, p)
+	}
+	if err := rows.Err(); err != nil {
+		http.Error(w, "Error fetching set cards", http.StatusInternalServerError)
+		log.Println("❌ Error reading set cards:", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"set":       code,
+		"page":      page,
+		"page_size": pageSize,
+		"total":     total,
+		"has_more":  page*pageSize < total,
+		"data":      printings,
+	})
+}
+
+func scanSet(row db.RowScanner) (models.Set, error) {
+	var set models.Set
+	err := row.Scan(&set.ID, &set.Code, &set.MTGOCode, &set.ArenaCode, &set.TCGPlayerID, &set.Name, &set.SetType, &set.ReleasedAt,
+		&set.BlockCode, &set.Block, &set.ParentSetCode, &set.CardCount, &set.PrintedSize, &set.Digital, &set.FoilOnly, &set.NonFoilOnly,
+		&set.ScryfallURI, &set.URI, &set.IconSVGURI, &set.SearchURI)
+	return set, err
+}
+
+// positiveParam parses the integer query parameter name, which must be at least 1 and, if max is
+// non-zero, at most max.
+func positiveParam(r *http.Request, name string, fallback, max int) (int, error) {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		return fallback, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 1 || (max > 0 && n > max) {
+		if max > 0 {
+			return 0, fmt.Errorf("Invalid '%s', expected 1-%d", name, max)
+		}
+		return 0, fmt.Errorf("Invalid '%s', expected a positive number", name)
+	}
+	return n, nil
+}
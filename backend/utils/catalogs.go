@@ -0,0 +1,80 @@
+package utils
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/quehorrifico/mana-tomb/backend/scryfall"
+)
+
+// refreshCatalog updates an API catalog such as the set list from fetch with a conditional
+// request, storing it with store unless the server answers that it has not changed. Its
+// validators and outcome are kept in catalog_validators, and the attempt is recorded as an
+// ingestion run. It returns the refresh status recorded for the catalog.
+func refreshCatalog[T any](ctx context.Context, db *sql.DB, catalog, runSource, trigger string,
+	fetch func(context.Context, scryfall.Validators) ([]T, scryfall.Validators, error), store func(*sql.DB, []T) error) (string, error) {
+	prev, err := catalogValidators(db, catalog)
+	if err != nil {
+		return "", err
+	}
+	runID, err := startIngestionRun(db, catalog, "", runSource, trigger)
+	if err != nil {
+		return "", err
+	}
+
+	var result IngestResult
+	items, validators, err := fetch(ctx, prev)
+	if errors.Is(err, scryfall.ErrNotModified) {
+		log.Printf("⏭️ %s not modified on the server, skipping\n", catalog)
+		finishIngestionRun(db, runID, runStatusNotModified, result, nil)
+		return refreshStatusNotModified, recordCatalogRefresh(db, catalog, refreshStatusNotModified, scryfall.Validators{})
+	}
+	if err == nil {
+		result.Cards = len(items)
+		err = store(db, items)
+	}
+	if err == nil {
+		// Only now keep the validators, so a failed run fetches the catalog in full next time
+		err = recordCatalogRefresh(db, catalog, refreshStatusRefreshed, validators)
+	}
+	finishIngestionRun(db, runID, runStatusSucceeded, result, err)
+	if err != nil {
+		return "", err
+	}
+
+	log.Printf("✅ Successfully stored %d %s\n", len(items), catalog)
+	return refreshStatusRefreshed, nil
+}
+
+// catalogValidators returns the validators of the last stored copy of catalog, if any.
+func catalogValidators(db *sql.DB, catalog string) (scryfall.Validators, error) {
+	var etag, lastModified sql.NullString
+	err := db.QueryRow(`SELECT etag, last_modified FROM catalog_validators WHERE catalog = $1`, catalog).Scan(&etag, &lastModified)
+	if err == sql.ErrNoRows {
+		return scryfall.Validators{}, nil
+	}
+	if err != nil {
+		return scryfall.Validators{}, fmt.Errorf("error loading validators of %s: %w", catalog, err)
+	}
+	return scryfall.Validators{ETag: etag.String, LastModified: lastModified.String}, nil
+}
+
+// recordCatalogRefresh records the outcome of refreshing catalog. The validators are only
+// replaced when the catalog was refreshed.
+func recordCatalogRefresh(db *sql.DB, catalog, status string, validators scryfall.Validators) error {
+	_, err := db.Exec(`
+		INSERT INTO catalog_validators (catalog, etag, last_modified, last_checked_at, last_refresh_status)
+		VALUES ($1, $2, $3, now(), $4)
+		ON CONFLICT (catalog) DO UPDATE
+		SET etag = CASE WHEN $4 = $5 THEN EXCLUDED.etag ELSE catalog_validators.etag END,
+		    last_modified = CASE WHEN $4 = $5 THEN EXCLUDED.last_modified ELSE catalog_validators.last_modified END,
+		    last_checked_at = now(), last_refresh_status = $4;
+	`, catalog, nullIfEmpty(validators.ETag), nullIfEmpty(validators.LastModified), status, refreshStatusRefreshed)
+	if err != nil {
+		return fmt.Errorf("error recording refresh of %s: %w", catalog, err)
+	}
+	return nil
+}
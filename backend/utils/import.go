@@ -0,0 +1,84 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/quehorrifico/mana-tomb/backend/scheduler"
+)
+
+// gzipMagic is the two-byte header every gzip stream starts with.
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// ImportBulkFile loads a Scryfall bulk JSON file from local disk into the table for bulkType.
+// Gzip-compressed files are detected by their header and decompressed while streaming. The import
+// is recorded as an ingestion run. Like a refresh, it holds the refresh lock throughout, and fails
+// with ErrRefreshRunning while a refresh or another import is running.
+func ImportBulkFile(db *sql.DB, bulkType, path string) (IngestResult, error) {
+	release, acquired, err := scheduler.TryLock(context.Background(), db, refreshLock)
+	if err != nil {
+		return IngestResult{}, err
+	}
+	if !acquired {
+		return IngestResult{}, ErrRefreshRunning
+	}
+	defer release()
+
+	log.Printf("📂 Importing %s from %s\n", bulkType, path)
+
+	runID, err := startIngestionRun(db, bulkType, "", path, TriggerImport)
+	if err != nil {
+		return IngestResult{}, err
+	}
+	result, err := importBulkFile(db, bulkType, path)
+	finishIngestionRun(db, runID, runStatusSucceeded, result, err)
+	return result, err
+}
+
+// importBulkFile does the work of ImportBulkFile.
+func importBulkFile(db *sql.DB, bulkType, path string) (IngestResult, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return IngestResult{}, fmt.Errorf("error opening bulk file: %w", err)
+	}
+	defer f.Close()
+
+	r, err := maybeGunzip(f)
+	if err != nil {
+		return IngestResult{}, err
+	}
+	defer r.Close()
+
+	result, err := IngestBulkStream(db, bulkType, r)
+	if err != nil {
+		return result, fmt.Errorf("error importing %s after %d cards: %w", path, result.Cards, err)
+	}
+
+	log.Printf("✅ Imported %d cards (%d bytes) from %s\n", result.Cards, result.Bytes, path)
+	return result, nil
+}
+
+// maybeGunzip returns a reader over r's contents, transparently decompressing gzip input.
+func maybeGunzip(r io.Reader) (io.ReadCloser, error) {
+	br := bufio.NewReader(r)
+	header, err := br.Peek(len(gzipMagic))
+	if err != nil && err != io.EOF {
+		return nil, fmt.Errorf("error reading bulk file header: %w", err)
+	}
+	if !bytes.Equal(header, gzipMagic) {
+		return io.NopCloser(br), nil
+	}
+
+	gz, err := gzip.NewReader(br)
+	if err != nil {
+		return nil, fmt.Errorf("error opening gzip stream: %w", err)
+	}
+	return gz, nil
+}
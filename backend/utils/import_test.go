@@ -0,0 +1,31 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/quehorrifico/mana-tomb/backend/scheduler"
+)
+
+func TestImportBulkFileWaitsForNoRefresh(t *testing.T) {
+	database := openTestDB(t)
+
+	release, acquired, err := scheduler.TryLock(context.Background(), database, refreshLock)
+	if err != nil || !acquired {
+		t.Fatalf("taking the refresh lock: acquired %v, %v", acquired, err)
+	}
+	_, err = ImportBulkFile(database, "oracle_cards", "../fixtures/oracle_cards.json")
+	if !errors.Is(err, ErrRefreshRunning) {
+		t.Errorf("import during a refresh returned %v, want ErrRefreshRunning", err)
+	}
+	release()
+
+	result, err := ImportBulkFile(database, "oracle_cards", "../fixtures/oracle_cards.json")
+	if err != nil {
+		t.Fatalf("import after the refresh: %v", err)
+	}
+	if want := fixtureLen(t, "oracle_cards"); result.Cards != want {
+		t.Errorf("imported %d cards, want %d", result.Cards, want)
+	}
+}
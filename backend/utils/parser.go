@@ -1,51 +1,34 @@
 package utils
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"log"
 	"strings"
+	"time"
 
 	"encoding/json"
 	"io"
-	"net/http"
 
 	"github.com/lib/pq"
 	"github.com/quehorrifico/mana-tomb/backend/models"
+	"github.com/quehorrifico/mana-tomb/backend/scryfall"
 )
 
-// FetchAndParseBulkData fetches bulk data from Scryfall and stores it in PostgreSQL (oracle_cards, unique_artwork, etc.)
-func FetchAndParseBulkData(db *sql.DB) {
+// FetchAndParseBulkData fetches the bulk data manifest from src, stores it in the bulk_data table and
+// returns the items of RefreshBulkTypes whose updated_at differs from the one they were last ingested at.
+func FetchAndParseBulkData(ctx context.Context, db *sql.DB, src scryfall.CardSource) ([]models.BulkData, error) {
 	log.Println("🔄 Fetching and storing bulk data items from Scryfall...")
-	resp, err := http.Get("https://api.scryfall.com/bulk-data")
+	bulkDataList, err := src.BulkData(ctx)
 	if err != nil {
-		log.Printf("Error fetching bulk data: %v\n", err)
-		return
+		return nil, err
 	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		log.Printf("Error reading response body: %v\n", err)
-		return
-	}
-
-	var bulkDataResponse models.BulkDataResponse
-
-	err = json.Unmarshal(body, &bulkDataResponse)
-	if err != nil {
-		log.Printf("Error unmarshaling JSON: %v\n", err)
-		return
-	}
-
-	// Now we have the correct slice of bulk data items
-	bulkDataList := bulkDataResponse.Data
 
 	// Insert/update data in PostgreSQL
 	tx, err := db.Begin()
 	if err != nil {
-		log.Printf("Error starting transaction: %v\n", err)
-		return
+		return nil, fmt.Errorf("error starting transaction: %w", err)
 	}
 	defer tx.Rollback()
 
@@ -64,204 +47,251 @@ func FetchAndParseBulkData(db *sql.DB) {
 		    content_encoding = EXCLUDED.content_encoding;
 	`)
 	if err != nil {
-		log.Printf("Error preparing statement: %v\n", err)
-		return
+		return nil, fmt.Errorf("error preparing statement: %w", err)
 	}
 	defer stmt.Close()
 
 	for _, data := range bulkDataList {
 		_, err := stmt.Exec(data.ID, data.URI, data.Type, data.Name, data.Description, data.DownloadURI, data.UpdatedAt, data.Size, data.ContentType, data.ContentEncoding)
 		if err != nil {
-			log.Printf("Error inserting bulk data: %v\n", err)
-			return
+			return nil, fmt.Errorf("error inserting bulk data: %w", err)
 		}
 	}
 
-	err = tx.Commit()
+	// Compare against ingested_updated_at rather than the previous updated_at, so an item whose
+	// last ingestion failed is retried even though the manifest row itself is already current.
+	rows, err := tx.Query(`
+		SELECT id, type, name, download_uri, updated_at
+		FROM bulk_data
+		WHERE type = ANY($1)
+		AND (ingested_updated_at IS NULL OR ingested_updated_at <> updated_at);
+	`, pq.Array(RefreshBulkTypes()))
 	if err != nil {
-		log.Printf("Error committing transaction: %v\n", err)
-		return
-	}
-
-	log.Println("Successfully updated bulk data.")
-}
-
-// FetchAndParseOracleCards fetches JSON from the download_uri and stores it in new tables
-func FetchAndParseOracleCards(db *sql.DB) {
-	log.Println("📥 Parsing oracle cards from bulk data...")
-
-	// Query database for all bulk data items with type "oracle_cards"
-	rows, err := db.Query("SELECT type, name, download_uri FROM bulk_data WHERE type = 'oracle_cards'")
-	if err != nil {
-		log.Printf("❌ Error querying bulk_data table: %v\n", err)
-		return
+		return nil, fmt.Errorf("error querying changed bulk data: %w", err)
 	}
 	defer rows.Close()
 
-	// Iterate over oracle_cards bulk data item
+	var changed []models.BulkData
 	for rows.Next() {
-		var dataType, unsafeTableName, downloadURI string
-		if err := rows.Scan(&dataType, &unsafeTableName, &downloadURI); err != nil {
-			log.Printf("❌ Error scanning row: %v\n", err)
-			continue
+		var data models.BulkData
+		var updatedAt time.Time
+		if err := rows.Scan(&data.ID, &data.Type, &data.Name, &data.DownloadURI, &updatedAt); err != nil {
+			return nil, fmt.Errorf("error scanning bulk data: %w", err)
 		}
-		tableName := sanitizeTableName(unsafeTableName)
+		data.UpdatedAt = updatedAt.Format(time.RFC3339Nano)
+		changed = append(changed, data)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error reading changed bulk data: %w", err)
+	}
 
-		log.Printf("📥 Fetching JSON for %s from %s\n", tableName, downloadURI)
+	err = tx.Commit()
+	if err != nil {
+		return nil, fmt.Errorf("error committing transaction: %w", err)
+	}
 
-		// Fetch the JSON payload
-		resp, err := http.Get(downloadURI)
-		if err != nil {
-			log.Printf("❌ Failed to fetch JSON from %s: %v\n", downloadURI, err)
-			continue
-		}
-		defer resp.Body.Close()
+	log.Printf("Successfully updated bulk data, %d item(s) changed.\n", len(changed))
+	return changed, nil
+}
 
-		body, err := io.ReadAll(resp.Body)
-		if err != nil {
-			log.Printf("❌ Error reading JSON response: %v\n", err)
-			continue
-		}
+// InsertOracleCards stages parsed JSON data for the oracle card table being loaded
+func InsertOracleCards(load *CardLoad, cards []models.OracleCard) error {
+	rows := make([][]interface{}, len(cards))
+	for i, card := range cards {
+		rows[i] = oracleCardRow(card)
+	}
+	return load.copyRows(rows)
+}
 
-		// Create oracle_cards table if it does not exist
-		err = EnsureOracleCardsTable(db)
-		if err != nil {
-			log.Printf("❌ Error creating table for %s: %v\n", tableName, err)
-			continue
-		}
+// oracleCardColumns are the columns written for each oracle card, in oracleCardRow order.
+var oracleCardColumns = []string{
+	"id", "oracle_id", "multiverse_ids", "mtgo_id", "mtgo_foil_id", "tcgplayer_id", "cardmarket_id",
+	"name", "lang", "released_at", "uri", "scryfall_uri", "layout", "highres_image",
+	"image_status", "image_uris", "mana_cost", "cmc", "type_line", "oracle_text", "colors",
+	"color_identity", "keywords", "legalities", "games", "reserved", "game_changer", "foil",
+	"nonfoil", "finishes", "oversized", "promo", "reprint", "variation", "set_id",
+	"set", "set_name", "set_type", "set_uri", "set_search_uri", "scryfall_set_uri", "rulings_uri",
+	"prints_search_uri", "collector_number", "digital", "rarity", "flavor_text", "card_back_id", "artist",
+	"artist_ids", "illustration_id", "border_color", "frame", "full_art", "textless", "booster",
+	"story_spotlight", "edhrec_rank", "prices", "card_faces",
+}
 
-		// Parse JSON
-		var oracleCards []models.OracleCard
-		if err := json.Unmarshal(body, &oracleCards); err != nil {
-			log.Printf("❌ Error unmarshaling JSON for %s: %v\n", tableName, err)
-			continue
-		}
+func oracleCardRow(card models.OracleCard) []interface{} {
+	imageURIsJSON, _ := json.Marshal(card.ImageURIs)
+	legalitiesJSON, _ := json.Marshal(card.Legalities)
+	pricesJSON, _ := json.Marshal(card.Prices)
+
+	return []interface{}{
+		card.ID, nullIfEmpty(card.OracleID), pq.Array(card.MultiverseIDs), card.MTGOID, card.MTGOFoilID, card.TCGPlayerID, card.CardMarketID,
+		card.Name, card.Lang, nullIfEmpty(card.ReleasedAt), card.URI, card.ScryfallURI, card.Layout, card.HighResImage, card.ImageStatus, imageURIsJSON,
+		card.ManaCost, card.CMC, card.TypeLine, card.OracleText, pq.Array(card.Colors), pq.Array(card.ColorIdentity), pq.Array(card.Keywords), legalitiesJSON,
+		pq.Array(card.Games), card.Reserved, card.GameChanger, card.Foil, card.NonFoil, pq.Array(card.Finishes), card.Oversized, card.Promo, card.Reprint, card.Variation,
+		nullIfEmpty(card.SetID), card.Set, card.SetName, card.SetType, card.SetURI, card.SetSearchURI, card.ScryfallSetURI, card.RulingsURI,
+		card.PrintsSearchURI, card.CollectorNumber, card.Digital, card.Rarity, card.FlavorText, nullIfEmpty(card.CardBackID), card.Artist,
+		pq.Array(card.ArtistIDs), nullIfEmpty(card.IllustrationID), card.BorderColor, card.Frame, card.FullArt, card.Textless, card.Booster,
+		card.StorySpotlight, card.EDHRecRank, pricesJSON, cardFacesJSON(card.CardFaces),
+	}
+}
 
-		// Insert parsed data into the appropriate table
-		err = InsertOracleCards(db, tableName, oracleCards)
-		if err != nil {
-			log.Printf("❌ Error inserting cards into %s: %v\n", tableName, err)
-		} else {
-			log.Printf("✅ Successfully inserted %d cards into %s\n", len(oracleCards), tableName)
-		}
+// InsertUniqueArtwork stages parsed JSON data for the unique artwork table being loaded
+func InsertUniqueArtwork(load *CardLoad, cards []models.UniqueArtworkCard) error {
+	rows := make([][]interface{}, len(cards))
+	for i, card := range cards {
+		rows[i] = uniqueArtworkRow(card)
 	}
+	return load.copyRows(rows)
 }
 
-// ParseAndParseUniqueArtwork fetches JSON from the download_uri and stores it in new tables
-func ParseAndParseUniqueArtwork(db *sql.DB) {
-	log.Println("📥 Parsing unique artwork from bulk data...")
+// uniqueArtworkColumns are the columns written for each unique artwork card, in uniqueArtworkRow order.
+var uniqueArtworkColumns = []string{
+	"id", "object", "oracle_id", "multiverse_ids", "mtgo_id", "mtgo_foil_id", "tcgplayer_id",
+	"cardmarket_id", "arena_id", "name", "lang", "released_at", "uri", "scryfall_uri",
+	"layout", "highres_image", "image_status", "image_uris", "mana_cost", "cmc", "type_line",
+	"oracle_text", "colors", "color_identity", "keywords", "legalities", "games", "reserved",
+	"game_changer", "foil", "nonfoil", "finishes", "oversized", "promo", "reprint",
+	"variation", "set_id", "set", "set_name", "set_type", "set_uri", "set_search_uri",
+	"scryfall_set_uri", "rulings_uri", "prints_search_uri", "collector_number", "digital", "rarity", "flavor_text",
+	"card_back_id", "artist", "artist_ids", "illustration_id", "border_color", "frame", "full_art",
+	"textless", "booster", "story_spotlight", "edhrec_rank", "penny_rank", "prices", "related_uris",
+	"card_faces",
+}
 
-	// Query database for all bulk data items with type "oracle_cards"
-	rows, err := db.Query("SELECT type, name, download_uri FROM bulk_data WHERE type = 'oracle_cards'")
-	if err != nil {
-		log.Printf("❌ Error querying bulk_data table: %v\n", err)
-		return
+func uniqueArtworkRow(card models.UniqueArtworkCard) []interface{} {
+	// Convert JSON struct fields to JSONB
+	imageURIsJSON, _ := json.Marshal(card.ImageURIs)
+	legalitiesJSON, _ := json.Marshal(card.Legalities)
+	pricesJSON, _ := json.Marshal(card.Prices)
+	relatedURIsJSON, _ := json.Marshal(card.RelatedURIs)
+
+	return []interface{}{
+		card.ID, card.Object, nullIfEmpty(card.OracleID), pq.Array(card.MultiverseIDs), card.MTGOID, card.MTGOFoilID, card.TCGPlayerID, card.CardMarketID, card.ArenaID,
+		card.Name, card.Lang, nullIfEmpty(card.ReleasedAt), card.URI, card.ScryfallURI, card.Layout, card.HighResImage, card.ImageStatus, imageURIsJSON,
+		card.ManaCost, card.CMC, card.TypeLine, card.OracleText, pq.Array(card.Colors), pq.Array(card.ColorIdentity), pq.Array(card.Keywords), legalitiesJSON,
+		pq.Array(card.Games), card.Reserved, card.GameChanger, card.Foil, card.NonFoil, pq.Array(card.Finishes), card.Oversized, card.Promo, card.Reprint, card.Variation,
+		nullIfEmpty(card.SetID), card.Set, card.SetName, card.SetType, card.SetURI, card.SetSearchURI, card.ScryfallSetURI, card.RulingsURI,
+		card.PrintsSearchURI, card.CollectorNumber, card.Digital, card.Rarity, card.FlavorText, nullIfEmpty(card.CardBackID), card.Artist,
+		pq.Array(card.ArtistIDs), nullIfEmpty(card.IllustrationID), card.BorderColor, card.Frame, card.FullArt, card.Textless, card.Booster,
+		card.StorySpotlight, card.EDHRecRank, card.PennyRank, pricesJSON, relatedURIsJSON, cardFacesJSON(card.CardFaces),
 	}
-	defer rows.Close()
-
-	// Iterate over oracle_cards bulk data item
-	for rows.Next() {
-		var dataType, unsafeTableName, downloadURI string
-		if err := rows.Scan(&dataType, &unsafeTableName, &downloadURI); err != nil {
-			log.Printf("❌ Error scanning row: %v\n", err)
-			continue
-		}
-		tableName := sanitizeTableName(unsafeTableName)
+}
 
-		log.Printf("📥 Fetching JSON for %s from %s\n", tableName, downloadURI)
+// InsertPrintings stages parsed default_cards/all_cards JSON data for the printings table
+func InsertPrintings(load *CardLoad, cards []models.Printing) error {
+	rows := make([][]interface{}, len(cards))
+	for i, card := range cards {
+		rows[i] = printingRow(card)
+	}
+	return load.copyRows(rows)
+}
 
-		// Fetch the JSON payload
-		resp, err := http.Get(downloadURI)
-		if err != nil {
-			log.Printf("❌ Failed to fetch JSON from %s: %v\n", downloadURI, err)
-			continue
-		}
-		defer resp.Body.Close()
+// printingColumns are the columns written for each printing, in printingRow order.
+var printingColumns = []string{
+	"id", "oracle_id", "multiverse_ids", "mtgo_id", "arena_id", "tcgplayer_id", "cardmarket_id",
+	"name", "lang", "printed_name", "printed_type_line", "printed_text", "released_at", "scryfall_uri",
+	"layout", "image_uris", "mana_cost",
+	"type_line", "games", "foil", "nonfoil", "finishes", "oversized", "promo",
+	"promo_types", "reprint", "variation", "variation_of", "set_id", "set", "set_name",
+	"set_type", "collector_number", "digital", "rarity", "artist", "illustration_id", "border_color",
+	"frame", "frame_effects", "full_art", "textless", "booster", "prices", "card_faces",
+}
 
-		body, err := io.ReadAll(resp.Body)
-		if err != nil {
-			log.Printf("❌ Error reading JSON response: %v\n", err)
-			continue
-		}
+func printingRow(card models.Printing) []interface{} {
+	imageURIsJSON, _ := json.Marshal(card.ImageURIs)
+	pricesJSON, _ := json.Marshal(card.Prices)
+
+	return []interface{}{
+		card.ID, nullIfEmpty(card.OracleID), pq.Array(card.MultiverseIDs), card.MTGOID, card.ArenaID, card.TCGPlayerID, card.CardMarketID,
+		card.Name, card.Lang, nullIfEmpty(printedName(card)), nullIfEmpty(card.PrintedTypeLine), nullIfEmpty(card.PrintedText),
+		nullIfEmpty(card.ReleasedAt), card.ScryfallURI, card.Layout, imageURIsJSON, card.ManaCost, card.TypeLine,
+		pq.Array(card.Games), card.Foil, card.NonFoil, pq.Array(card.Finishes), card.Oversized, card.Promo, pq.Array(card.PromoTypes), card.Reprint, card.Variation, nullIfEmpty(card.VariationOf),
+		nullIfEmpty(card.SetID), card.Set, card.SetName, card.SetType, card.CollectorNumber, card.Digital, card.Rarity, card.Artist, nullIfEmpty(card.IllustrationID),
+		card.BorderColor, card.Frame, pq.Array(card.FrameEffects), card.FullArt, card.Textless, card.Booster, pricesJSON, cardFacesJSON(card.CardFaces),
+	}
+}
 
-		// Create oracle_cards table if it does not exist
-		err = EnsureOracleCardsTable(db)
-		if err != nil {
-			log.Printf("❌ Error creating table for %s: %v\n", tableName, err)
-			continue
+// printedName returns the printed name of a printing. Multi-faced printings only have printed
+// names on their faces, which are joined like Scryfall joins English face names.
+func printedName(card models.Printing) string {
+	if card.PrintedName != "" || len(card.CardFaces) == 0 {
+		return card.PrintedName
+	}
+	names := make([]string, 0, len(card.CardFaces))
+	for _, face := range card.CardFaces {
+		if face.PrintedName == "" {
+			return ""
 		}
+		names = append(names, face.PrintedName)
+	}
+	return strings.Join(names, " // ")
+}
 
-		// Parse JSON
-		var oracleCards []models.UniqueArtworkCard
-		if err := json.Unmarshal(body, &oracleCards); err != nil {
-			log.Printf("❌ Error unmarshaling JSON for %s: %v\n", tableName, err)
-			continue
-		}
+// SnapshotPrices records the current prices of every live printing as today's snapshot. Printings
+// ingestion already does this, but it is skipped on days Scryfall's default_cards file is unchanged.
+func SnapshotPrices(ctx context.Context, db *sql.DB) error {
+	snapshotDate := time.Now().UTC().Format("2006-01-02")
+	res, err := db.ExecContext(ctx, priceSnapshotSQL("printings"), snapshotDate)
+	if err != nil {
+		return fmt.Errorf("error recording price snapshot: %w", err)
+	}
+	n, _ := res.RowsAffected()
+	log.Printf("💰 Recorded %s prices of %d printings\n", snapshotDate, n)
+	return nil
+}
 
-		// Insert parsed data into the appropriate table
-		err = InsertUniqueArtwork(db, tableName, oracleCards)
-		if err != nil {
-			log.Printf("❌ Error inserting cards into %s: %v\n", tableName, err)
-		} else {
-			log.Printf("✅ Successfully inserted %d cards into %s\n", len(oracleCards), tableName)
+// snapshotPriceHistory records the staged prices of each printing as its snapshot for snapshotDate.
+// It runs inside the printings merge, so a snapshot is only kept if its printings were.
+func snapshotPriceHistory(snapshotDate string) func(tx *sql.Tx, staging string) error {
+	return func(tx *sql.Tx, staging string) error {
+		if _, err := tx.Exec(priceSnapshotSQL(staging), snapshotDate); err != nil {
+			return fmt.Errorf("error recording price snapshot: %w", err)
 		}
+		return nil
 	}
 }
 
-// InsertOracleCards inserts parsed JSON data into the correct table
-func InsertOracleCards(db *sql.DB, tableName string, cards []models.OracleCard) error {
+// priceSnapshotSQL copies the prices of every non-retired printing in table into price_history as
+// the snapshot for the date $1. Missing prices are stored as "" in the prices JSON and become NULL.
+func priceSnapshotSQL(table string) string {
+	return fmt.Sprintf(`
+		INSERT INTO price_history (printing_id, snapshot_date, usd, usd_foil, usd_etched, eur, eur_foil, tix)
+		SELECT id, $1,
+		       NULLIF(prices->>'usd', '')::numeric, NULLIF(prices->>'usd_foil', '')::numeric, NULLIF(prices->>'usd_etched', '')::numeric,
+		       NULLIF(prices->>'eur', '')::numeric, NULLIF(prices->>'eur_foil', '')::numeric, NULLIF(prices->>'tix', '')::numeric
+		FROM %s
+		WHERE retired_at IS NULL
+		ON CONFLICT (printing_id, snapshot_date) DO UPDATE
+		SET usd = EXCLUDED.usd,
+		    usd_foil = EXCLUDED.usd_foil,
+		    usd_etched = EXCLUDED.usd_etched,
+		    eur = EXCLUDED.eur,
+		    eur_foil = EXCLUDED.eur_foil,
+		    tix = EXCLUDED.tix;
+	`, table)
+}
+
+// InsertRulings inserts parsed rulings JSON data, skipping rulings that are already stored
+func InsertRulings(db *sql.DB, rulings []models.Ruling) error {
 	tx, err := db.Begin()
 	if err != nil {
 		return fmt.Errorf("error starting transaction: %w", err)
 	}
 	defer tx.Rollback()
 
-	stmt, err := tx.Prepare(fmt.Sprintf(`
-		INSERT INTO %s (
-			id, oracle_id, multiverse_ids, mtgo_id, mtgo_foil_id, tcgplayer_id, cardmarket_id,
-			name, lang, released_at, uri, scryfall_uri, layout, highres_image, image_status, image_uris,
-			mana_cost, cmc, type_line, oracle_text, colors, color_identity, keywords, legalities,
-			games, reserved, game_changer, foil, nonfoil, finishes, oversized, promo, reprint, variation,
-			set_id, set, set_name, set_type, set_uri, set_search_uri, scryfall_set_uri, rulings_uri,
-			prints_search_uri, collector_number, digital, rarity, flavor_text, card_back_id, artist,
-			artist_ids, illustration_id, border_color, frame, full_art, textless, booster,
-			story_spotlight, edhrec_rank, prices
-		) VALUES (
-			$1, $2, $3, $4, $5, $6, $7,
-			$8, $9, $10, $11, $12, $13, $14, $15, $16,
-			$17, $18, $19, $20, $21, $22, $23, $24,
-			$25, $26, $27, $28, $29, $30, $31, $32, $33, $34,
-			$35, $36, $37, $38, $39, $40, $41, $42,
-			$43, $44, $45, $46, $47, $48, $49,
-			$50, $51, $52, $53, $54, $55, $56,
-			$57, $58, $59
-		)
-		ON CONFLICT (id) DO NOTHING;
-	`, tableName))
+	stmt, err := tx.Prepare(`
+		INSERT INTO rulings (oracle_id, source, published_at, comment)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (oracle_id, source, published_at, md5(comment)) DO NOTHING;
+	`)
 	if err != nil {
 		return fmt.Errorf("error preparing statement: %w", err)
 	}
 	defer stmt.Close()
 
-	for _, card := range cards {
-		imageURIsJSON, _ := json.Marshal(card.ImageURIs)
-		legalitiesJSON, _ := json.Marshal(card.Legalities)
-		pricesJSON, _ := json.Marshal(card.Prices)
-
-		args := []interface{}{
-			card.ID, card.OracleID, pq.Array(card.MultiverseIDs), card.MTGOID, card.MTGOFoilID, card.TCGPlayerID, card.CardMarketID,
-			card.Name, card.Lang, card.ReleasedAt, card.URI, card.ScryfallURI, card.Layout, card.HighResImage, card.ImageStatus, imageURIsJSON,
-			card.ManaCost, card.CMC, card.TypeLine, card.OracleText, pq.Array(card.Colors), pq.Array(card.ColorIdentity), pq.Array(card.Keywords), legalitiesJSON,
-			pq.Array(card.Games), card.Reserved, card.GameChanger, card.Foil, card.NonFoil, pq.Array(card.Finishes), card.Oversized, card.Promo, card.Reprint, card.Variation,
-			card.SetID, card.Set, card.SetName, card.SetType, card.SetURI, card.SetSearchURI, card.ScryfallSetURI, card.RulingsURI,
-			card.PrintsSearchURI, card.CollectorNumber, card.Digital, card.Rarity, card.FlavorText, card.CardBackID, card.Artist,
-			pq.Array(card.ArtistIDs), card.IllustrationID, card.BorderColor, card.Frame, card.FullArt, card.Textless, card.Booster,
-			card.StorySpotlight, card.EDHRecRank, pricesJSON,
-		}
-		_, err = stmt.Exec(args...)
+	for _, ruling := range rulings {
+		// A failed statement aborts the whole transaction, so stop at the first error
+		_, err = stmt.Exec(ruling.OracleID, ruling.Source, ruling.PublishedAt, ruling.Comment)
 		if err != nil {
-			log.Printf("⚠️ Error inserting card %s: %v", card.Name, err)
-			continue
+			return fmt.Errorf("error inserting ruling for %s: %w", ruling.OracleID, err)
 		}
 	}
 
@@ -269,70 +299,112 @@ func InsertOracleCards(db *sql.DB, tableName string, cards []models.OracleCard)
 	return err
 }
 
-// InsertUniqueArtwork inserts parsed JSON data into the correct table
-func InsertUniqueArtwork(db *sql.DB, tableName string, cards []models.UniqueArtworkCard) error {
-	tx, err := db.Begin()
-	if err != nil {
-		return fmt.Errorf("error starting transaction: %w", err)
+// cardFacesJSON encodes card faces for the card_faces JSONB column, using [] rather than null for
+// single-faced cards so the column can always be expanded with jsonb_array_elements.
+func cardFacesJSON(faces []models.CardFace) []byte {
+	if len(faces) == 0 {
+		return []byte("[]")
 	}
-	defer tx.Rollback()
+	facesJSON, _ := json.Marshal(faces)
+	return facesJSON
+}
 
-	stmt, err := tx.Prepare(fmt.Sprintf(`
-		INSERT INTO %s (
-			id, object, oracle_id, multiverse_ids, mtgo_id, mtgo_foil_id, tcgplayer_id, cardmarket_id, arena_id,
-			name, lang, released_at, uri, scryfall_uri, layout, highres_image, image_status, image_uris,
-			mana_cost, cmc, type_line, oracle_text, colors, color_identity, keywords, legalities,
-			games, reserved, game_changer, foil, nonfoil, finishes, oversized, promo, reprint, variation,
-			set_id, set, set_name, set_type, set_uri, set_search_uri, scryfall_set_uri, rulings_uri,
-			prints_search_uri, collector_number, digital, rarity, flavor_text, card_back_id, artist,
-			artist_ids, illustration_id, border_color, frame, full_art, textless, booster,
-			story_spotlight, edhrec_rank, penny_rank, prices, related_uris
-		) VALUES (
-			$1, $2, $3, $4, $5, $6, $7, $8, $9,
-			$10, $11, $12, $13, $14, $15, $16, $17, $18,
-			$19, $20, $21, $22, $23, $24, $25, $26,
-			$27, $28, $29, $30, $31, $32, $33, $34, $35,
-			$36, $37, $38, $39, $40, $41, $42, $43,
-			$44, $45, $46, $47, $48, $49, $50,
-			$51, $52, $53, $54, $55, $56, $57,
-			$58, $59, $60, $61, $62, $63
-		)
-		ON CONFLICT (id) DO NOTHING;
-	`, tableName))
-	if err != nil {
-		return fmt.Errorf("error preparing statement: %w", err)
+// nullIfEmpty maps Scryfall's missing values (decoded as "") to NULL, so they fit UUID and DATE columns.
+func nullIfEmpty(s string) interface{} {
+	if s == "" {
+		return nil
 	}
-	defer stmt.Close()
+	return s
+}
 
-	for _, card := range cards {
-		// Convert JSON struct fields to JSONB
-		imageURIsJSON, _ := json.Marshal(card.ImageURIs)
-		legalitiesJSON, _ := json.Marshal(card.Legalities)
-		pricesJSON, _ := json.Marshal(card.Prices)
-		relatedURIsJSON, _ := json.Marshal(card.RelatedURIs)
-
-		args := []interface{}{
-			card.ID, card.Object, card.OracleID, pq.Array(card.MultiverseIDs), card.MTGOID, card.MTGOFoilID, card.TCGPlayerID, card.CardMarketID, card.ArenaID,
-			card.Name, card.Lang, card.ReleasedAt, card.URI, card.ScryfallURI, card.Layout, card.HighResImage, card.ImageStatus, imageURIsJSON,
-			card.ManaCost, card.CMC, card.TypeLine, card.OracleText, pq.Array(card.Colors), pq.Array(card.ColorIdentity), pq.Array(card.Keywords), legalitiesJSON,
-			pq.Array(card.Games), card.Reserved, card.GameChanger, card.Foil, card.NonFoil, pq.Array(card.Finishes), card.Oversized, card.Promo, card.Reprint, card.Variation,
-			card.SetID, card.Set, card.SetName, card.SetType, card.SetURI, card.SetSearchURI, card.ScryfallSetURI, card.RulingsURI,
-			card.PrintsSearchURI, card.CollectorNumber, card.Digital, card.Rarity, card.FlavorText, card.CardBackID, card.Artist,
-			pq.Array(card.ArtistIDs), card.IllustrationID, card.BorderColor, card.Frame, card.FullArt, card.Textless, card.Booster,
-			card.StorySpotlight, card.EDHRecRank, card.PennyRank, pricesJSON, relatedURIsJSON,
+// sanitizeTableName makes sure the table name is safe for SQL
+func sanitizeTableName(name string) string {
+	return strings.ReplaceAll(strings.ToLower(name), " ", "_")
+}
+
+// IngestResult is the outcome of ingesting one bulk file.
+type IngestResult struct {
+	IngestProgress
+	Changelog *Changelog                 // nil for bulk types that are not card tables, like rulings
+	Rejected  []models.IngestionRunError // cards left out because they failed validation
+}
+
+// IngestBulkStream decodes a Scryfall bulk file of the given type from r and writes it to the
+// table for that type, using the same insert code as the scheduled download. oracle_cards and
+// unique_artwork and rulings have tables of their own; default_cards and all_cards both feed printings.
+// "sets" and "symbology" read the set catalog and the card symbols as served by /sets and /symbology.
+// Card tables are staged with COPY and merged in one transaction once the whole file is in, retiring
+// cards the file no longer contains.
+func IngestBulkStream(db *sql.DB, bulkType string, r io.Reader) (IngestResult, error) {
+	tableName := sanitizeTableName(bulkType)
+	switch bulkType {
+	case "oracle_cards":
+		return ingestCardTable(db, cardTable{name: tableName, columns: oracleCardColumns}, r, InsertOracleCards, nil)
+	case "unique_artwork":
+		return ingestCardTable(db, cardTable{name: tableName, columns: uniqueArtworkColumns}, r, InsertUniqueArtwork, nil)
+	case "default_cards", "all_cards":
+		// Every printings ingestion also appends today's price snapshot to price_history. Each file
+		// only retires the printings it loaded, so switching between them keeps the other's printings
+		snapshotDate := time.Now().UTC().Format("2006-01-02")
+		t := cardTable{name: "printings", columns: printingColumns, source: bulkType}
+		return ingestCardTable(db, t, r, InsertPrintings, snapshotPriceHistory(snapshotDate))
+	case "sets":
+		// The set catalog is one small list object rather than a bulk array
+		counter := &countingReader{r: r}
+		sets, err := decodeSetList(counter)
+		if err != nil {
+			return IngestResult{}, err
 		}
-		_, err = stmt.Exec(args...)
+		result := IngestResult{IngestProgress: IngestProgress{Cards: len(sets), Bytes: counter.n}}
+		return result, InsertSets(db, sets)
+	case "symbology":
+		counter := &countingReader{r: r}
+		symbols, err := decodeSymbology(counter)
 		if err != nil {
-			log.Printf("⚠️ Error inserting card %s: %v", card.Name, err)
-			continue
+			return IngestResult{}, err
 		}
+		result := IngestResult{IngestProgress: IngestProgress{Cards: len(symbols), Bytes: counter.n}}
+		return result, InsertCardSymbols(db, symbols)
+	case "rulings":
+		progress, err := StreamBulkArray(r, ingestBatchSize, func(batch []models.Ruling) error {
+			return InsertRulings(db, batch)
+		}, logIngestProgress(tableName))
+		return IngestResult{IngestProgress: progress}, err
+	default:
+		return IngestResult{}, fmt.Errorf("unsupported bulk data type %q", bulkType)
 	}
-
-	err = tx.Commit()
-	return err
 }
 
-// sanitizeTableName makes sure the table name is safe for SQL
-func sanitizeTableName(name string) string {
-	return strings.ReplaceAll(strings.ToLower(name), " ", "_")
+// ingestCardTable streams a bulk file of cards into the staging table of t with insert, then merges
+// it into t. afterMerge is passed on to CardLoad.Merge.
+func ingestCardTable[T any](db *sql.DB, t cardTable, r io.Reader, insert func(*CardLoad, []T) error, afterMerge func(*sql.Tx, string) error) (IngestResult, error) {
+	var result IngestResult
+
+	load, err := newCardLoad(db, t)
+	if err != nil {
+		return result, err
+	}
+	defer func() {
+		if err := load.Close(); err != nil {
+			log.Println("⚠️", err)
+		}
+	}()
+
+	result.IngestProgress, err = StreamBulkArray(r, ingestBatchSize, func(batch []T) error {
+		return insert(load, batch)
+	}, logIngestProgress(t.name))
+	result.Rejected = load.Rejected
+	if err != nil {
+		return result, err
+	}
+
+	if err := load.Merge(afterMerge); err != nil {
+		return result, err
+	}
+	result.Changelog = load.Changelog
+	if len(result.Rejected) > 0 {
+		log.Printf("⚠️ Rejected %d invalid card(s) for %s\n", len(result.Rejected), t.name)
+	}
+	log.Printf("📋 Changelog for %s\n", result.Changelog)
+	return result, nil
 }
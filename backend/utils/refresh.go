@@ -0,0 +1,234 @@
+package utils
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/quehorrifico/mana-tomb/backend/autocomplete"
+	"github.com/quehorrifico/mana-tomb/backend/models"
+	"github.com/quehorrifico/mana-tomb/backend/scheduler"
+	"github.com/quehorrifico/mana-tomb/backend/scryfall"
+)
+
+// RefreshBulkTypes lists the Scryfall bulk data types kept up to date by RefreshBulkData. With
+// SCRYFALL_ALL_CARDS=on, printings come from all_cards, which adds every non-English printing to
+// default_cards, instead of from default_cards.
+func RefreshBulkTypes() []string {
+	printings := "default_cards"
+	if os.Getenv("SCRYFALL_ALL_CARDS") == "on" {
+		printings = "all_cards"
+	}
+	return []string{"oracle_cards", "unique_artwork", printings, "rulings"}
+}
+
+// Refresh statuses recorded in bulk_data.last_refresh_status.
+const (
+	refreshStatusRefreshed   = "refreshed"
+	refreshStatusUnchanged   = "unchanged"
+	refreshStatusNotModified = "not_modified"
+	refreshStatusFailed      = "failed"
+)
+
+// ErrRefreshRunning is returned while another refresh, in this or another instance, is still running.
+var ErrRefreshRunning = errors.New("a bulk data refresh is already running")
+
+// refreshLock is the advisory lock held for the whole of every refresh.
+const refreshLock = "bulk_refresh"
+
+// RefreshReport records what a refresh run did with each bulk data type.
+type RefreshReport struct {
+	Refreshed []string // downloaded and re-ingested
+	Skipped   []string // updated_at unchanged, or the server answered 304 Not Modified
+	Failed    []string // download or ingestion failed; retried on the next run
+}
+
+func (r RefreshReport) String() string {
+	return fmt.Sprintf("refreshed [%s], skipped [%s], failed [%s]",
+		strings.Join(r.Refreshed, ", "), strings.Join(r.Skipped, ", "), strings.Join(r.Failed, ", "))
+}
+
+// RunBulkRefresh runs RefreshBulkData unless a refresh is already running, in which case it
+// returns ErrRefreshRunning.
+func RunBulkRefresh(ctx context.Context, db *sql.DB, src scryfall.CardSource, trigger string) (RefreshReport, error) {
+	release, acquired, err := scheduler.TryLock(ctx, db, refreshLock)
+	if err != nil {
+		return RefreshReport{}, err
+	}
+	if !acquired {
+		return RefreshReport{}, ErrRefreshRunning
+	}
+	defer release()
+
+	return RefreshBulkData(ctx, db, src, trigger), nil
+}
+
+// StartBulkRefresh runs RefreshBulkData in the background unless a refresh is already running, in
+// which case it returns ErrRefreshRunning.
+func StartBulkRefresh(db *sql.DB, src scryfall.CardSource, trigger string) error {
+	ctx := context.Background()
+	release, acquired, err := scheduler.TryLock(ctx, db, refreshLock)
+	if err != nil {
+		return err
+	}
+	if !acquired {
+		return ErrRefreshRunning
+	}
+	go func() {
+		defer release()
+		RefreshBulkData(ctx, db, src, trigger)
+	}()
+	return nil
+}
+
+// RefreshBulkData updates the bulk data manifest from src and re-ingests only the bulk files that
+// changed since they were last ingested, and updates the set catalog and symbology. The outcome for
+// each bulk type is stored on its bulk_data row, and every download attempt is recorded as an
+// ingestion run started by trigger.
+func RefreshBulkData(ctx context.Context, db *sql.DB, src scryfall.CardSource, trigger string) RefreshReport {
+	var report RefreshReport
+
+	changed, err := FetchAndParseBulkData(ctx, db, src)
+	if err != nil {
+		log.Printf("❌ Error refreshing bulk data manifest: %v\n", err)
+		report.Failed = append(report.Failed, RefreshBulkTypes()...)
+		return report
+	}
+
+	// The set catalog and symbology are not in the manifest; they are fetched with conditional
+	// requests every run instead
+	for _, catalog := range []struct {
+		name    string
+		refresh func(context.Context, *sql.DB, scryfall.CardSource, string) (string, error)
+	}{{"sets", RefreshSets}, {"symbology", RefreshSymbology}} {
+		status, err := catalog.refresh(ctx, db, src, trigger)
+		switch {
+		case err != nil:
+			log.Printf("❌ Error refreshing %s: %v\n", catalog.name, err)
+			if err := recordCatalogRefresh(db, catalog.name, refreshStatusFailed, scryfall.Validators{}); err != nil {
+				log.Printf("⚠️ %v\n", err)
+			}
+			report.Failed = append(report.Failed, catalog.name)
+		case status == refreshStatusNotModified:
+			report.Skipped = append(report.Skipped, catalog.name)
+		default:
+			report.Refreshed = append(report.Refreshed, catalog.name)
+		}
+	}
+
+	changedByType := make(map[string]models.BulkData, len(changed))
+	for _, item := range changed {
+		changedByType[item.Type] = item
+	}
+
+	for _, bulkType := range RefreshBulkTypes() {
+		item, ok := changedByType[bulkType]
+		if !ok {
+			log.Printf("⏭️ %s unchanged since last ingestion, skipping\n", bulkType)
+			markBulkDataChecked(db, bulkType, refreshStatusUnchanged)
+			report.Skipped = append(report.Skipped, bulkType)
+			continue
+		}
+
+		status, err := refreshBulkItem(ctx, db, src, item, trigger)
+		if err != nil {
+			log.Printf("❌ Error refreshing %s: %v\n", bulkType, err)
+			markBulkDataChecked(db, bulkType, refreshStatusFailed)
+			report.Failed = append(report.Failed, bulkType)
+			continue
+		}
+		if status == refreshStatusNotModified {
+			report.Skipped = append(report.Skipped, bulkType)
+		} else {
+			report.Refreshed = append(report.Refreshed, bulkType)
+		}
+	}
+
+	log.Printf("📋 Bulk refresh finished: %s\n", report)
+	return report
+}
+
+// refreshBulkItem downloads one bulk file with a conditional request and ingests it unless the
+// source reports it unchanged, recording the attempt as an ingestion run. It returns the refresh
+// status recorded for the item.
+func refreshBulkItem(ctx context.Context, db *sql.DB, src scryfall.CardSource, item models.BulkData, trigger string) (string, error) {
+	runID, err := startIngestionRun(db, item.Type, item.ID, item.DownloadURI, trigger)
+	if err != nil {
+		return "", err
+	}
+
+	status, result, err := downloadBulkItem(ctx, db, src, item)
+	runStatus := runStatusSucceeded
+	if status == refreshStatusNotModified {
+		runStatus = runStatusNotModified
+	}
+	finishIngestionRun(db, runID, runStatus, result, err)
+	if item.Type == "oracle_cards" && status == refreshStatusRefreshed {
+		autocomplete.Invalidate()
+	}
+	return status, err
+}
+
+// downloadBulkItem does the work of refreshBulkItem.
+func downloadBulkItem(ctx context.Context, db *sql.DB, src scryfall.CardSource, item models.BulkData) (string, IngestResult, error) {
+	var etag, lastModified sql.NullString
+	err := db.QueryRow(`SELECT etag, last_modified FROM bulk_data WHERE id = $1`, item.ID).Scan(&etag, &lastModified)
+	if err != nil {
+		return "", IngestResult{}, fmt.Errorf("error loading validators: %w", err)
+	}
+
+	log.Printf("📥 Fetching JSON for %s from %s\n", item.Type, item.DownloadURI)
+	file, validators, err := src.OpenBulkFile(ctx, item, scryfall.Validators{ETag: etag.String, LastModified: lastModified.String})
+	if errors.Is(err, scryfall.ErrNotModified) {
+		log.Printf("⏭️ %s not modified on the server, skipping\n", item.Type)
+		_, err = db.Exec(`
+			UPDATE bulk_data
+			SET ingested_updated_at = updated_at, last_checked_at = now(), last_refresh_status = $2
+			WHERE id = $1;
+		`, item.ID, refreshStatusNotModified)
+		if err != nil {
+			return "", IngestResult{}, fmt.Errorf("error recording refresh: %w", err)
+		}
+		return refreshStatusNotModified, IngestResult{}, nil
+	}
+	if err != nil {
+		return "", IngestResult{}, err
+	}
+	defer file.Close()
+
+	body, err := maybeGunzip(file)
+	if err != nil {
+		return "", IngestResult{}, err
+	}
+	defer body.Close()
+
+	result, err := IngestBulkStream(db, item.Type, body)
+	if err != nil {
+		return "", result, fmt.Errorf("error ingesting after %d cards: %w", result.Cards, err)
+	}
+	log.Printf("✅ Successfully ingested %d cards (%d bytes) for %s\n", result.Cards, result.Bytes, item.Type)
+
+	// Only now mark the file as ingested, so a failed run is retried next time
+	_, err = db.Exec(`
+		UPDATE bulk_data
+		SET etag = $2, last_modified = $3, ingested_updated_at = $4,
+		    last_checked_at = now(), last_refresh_status = $5
+		WHERE id = $1;
+	`, item.ID, validators.ETag, validators.LastModified, item.UpdatedAt, refreshStatusRefreshed)
+	if err != nil {
+		return "", result, fmt.Errorf("error recording refresh: %w", err)
+	}
+	return refreshStatusRefreshed, result, nil
+}
+
+// markBulkDataChecked records that bulkType was looked at in this run without being re-ingested.
+func markBulkDataChecked(db *sql.DB, bulkType, status string) {
+	_, err := db.Exec(`UPDATE bulk_data SET last_checked_at = now(), last_refresh_status = $2 WHERE type = $1`, bulkType, status)
+	if err != nil {
+		log.Printf("⚠️ Error recording refresh status for %s: %v\n", bulkType, err)
+	}
+}
@@ -0,0 +1,234 @@
+package utils
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"os"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/quehorrifico/mana-tomb/backend/models"
+	"github.com/quehorrifico/mana-tomb/backend/scryfall"
+	"github.com/quehorrifico/mana-tomb/backend/scryfall/scryfalltest"
+)
+
+// fixtureLen returns the number of objects in the fixture bulk file for bulkType.
+func fixtureLen(t *testing.T, bulkType string) int {
+	t.Helper()
+	data, err := os.ReadFile("../fixtures/" + bulkType + ".json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var objects []json.RawMessage
+	if err := json.Unmarshal(data, &objects); err != nil {
+		t.Fatal(err)
+	}
+	return len(objects)
+}
+
+func countRows(t *testing.T, database *sql.DB, query string, args ...interface{}) int {
+	t.Helper()
+	var n int
+	if err := database.QueryRow(query, args...).Scan(&n); err != nil {
+		t.Fatalf("%s: %v", query, err)
+	}
+	return n
+}
+
+// TestFakeScryfallBulkFile streams a bulk file from the fake server through the parser without a
+// database, then checks a conditional request for the same file is answered Not Modified.
+func TestFakeScryfallBulkFile(t *testing.T) {
+	ctx := context.Background()
+	server := scryfalltest.NewServer(os.DirFS("../fixtures"))
+	defer server.Close()
+	src := server.Source()
+
+	items, err := src.BulkData(ctx)
+	if err != nil {
+		t.Fatalf("fetching manifest: %v", err)
+	}
+	var item models.BulkData
+	for _, it := range items {
+		if it.Type == "oracle_cards" {
+			item = it
+		}
+	}
+	if item.Type == "" {
+		t.Fatalf("manifest has no oracle_cards item: %+v", items)
+	}
+
+	file, validators, err := src.OpenBulkFile(ctx, item, scryfall.Validators{})
+	if err != nil {
+		t.Fatalf("opening oracle_cards: %v", err)
+	}
+	var rows [][]interface{}
+	progress, err := StreamBulkArray(file, 2, func(cards []models.OracleCard) error {
+		for _, card := range cards {
+			rows = append(rows, oracleCardRow(card))
+		}
+		return nil
+	}, nil)
+	file.Close()
+	if err != nil {
+		t.Fatalf("streaming oracle_cards: %v", err)
+	}
+	if want := fixtureLen(t, "oracle_cards"); progress.Cards != want || len(rows) != want {
+		t.Errorf("streamed %d cards into %d rows, want %d", progress.Cards, len(rows), want)
+	}
+	if validators.ETag == "" {
+		t.Error("no ETag returned for the download")
+	}
+
+	_, _, err = src.OpenBulkFile(ctx, item, validators)
+	if !errors.Is(err, scryfall.ErrNotModified) {
+		t.Errorf("conditional request returned %v, want ErrNotModified", err)
+	}
+	if got := server.Downloads("oracle_cards"); got != 1 {
+		t.Errorf("oracle_cards downloaded %d times, want 1", got)
+	}
+}
+
+// TestFakeScryfallCatalogs checks the set and symbology catalogs are answered Not Modified when
+// requested again with the validators of the first response.
+func TestFakeScryfallCatalogs(t *testing.T) {
+	ctx := context.Background()
+	server := scryfalltest.NewServer(os.DirFS("../fixtures"))
+	defer server.Close()
+	src := server.Source()
+
+	sets, validators, err := src.Sets(ctx, scryfall.Validators{})
+	if err != nil {
+		t.Fatalf("fetching sets: %v", err)
+	}
+	if len(sets) == 0 || validators.ETag == "" {
+		t.Fatalf("got %d sets with validators %+v", len(sets), validators)
+	}
+	if _, _, err := src.Sets(ctx, validators); !errors.Is(err, scryfall.ErrNotModified) {
+		t.Errorf("conditional sets request returned %v, want ErrNotModified", err)
+	}
+
+	symbols, validators, err := src.Symbology(ctx, scryfall.Validators{})
+	if err != nil {
+		t.Fatalf("fetching symbology: %v", err)
+	}
+	if len(symbols) == 0 || validators.ETag == "" {
+		t.Fatalf("got %d symbols with validators %+v", len(symbols), validators)
+	}
+	if _, _, err := src.Symbology(ctx, validators); !errors.Is(err, scryfall.ErrNotModified) {
+		t.Errorf("conditional symbology request returned %v, want ErrNotModified", err)
+	}
+}
+
+func TestRunBulkRefreshAgainstFakeScryfall(t *testing.T) {
+	database := openTestDB(t)
+	ctx := context.Background()
+	server := scryfalltest.NewServer(os.DirFS("../fixtures"))
+	defer server.Close()
+
+	// First run: every bulk file is new, so all of them are downloaded and ingested
+	report, err := RunBulkRefresh(ctx, database, server.Source(), TriggerManual)
+	if err != nil {
+		t.Fatalf("first refresh: %v", err)
+	}
+	if len(report.Failed) > 0 {
+		t.Fatalf("first refresh failed for %v", report.Failed)
+	}
+	for _, bulkType := range RefreshBulkTypes() {
+		if !slices.Contains(report.Refreshed, bulkType) {
+			t.Errorf("first refresh did not refresh %s: %s", bulkType, report)
+		}
+	}
+	for _, catalog := range []string{"sets", "symbology"} {
+		if !slices.Contains(report.Refreshed, catalog) {
+			t.Errorf("first refresh did not refresh %s: %s", catalog, report)
+		}
+	}
+
+	for _, c := range []struct {
+		table, bulkType string
+	}{
+		{"oracle_cards", "oracle_cards"},
+		{"unique_artwork", "unique_artwork"},
+		{"printings", "default_cards"},
+		{"rulings", "rulings"},
+	} {
+		want := fixtureLen(t, c.bulkType)
+		if got := countRows(t, database, `SELECT count(*) FROM `+c.table); got != want {
+			t.Errorf("%s has %d rows, want %d from %s.json", c.table, got, want, c.bulkType)
+		}
+	}
+	if got := countRows(t, database, `SELECT count(*) FROM ingestion_runs WHERE status = $1 AND bulk_type = ANY($2)`,
+		runStatusSucceeded, pq.Array(RefreshBulkTypes())); got != len(RefreshBulkTypes()) {
+		t.Errorf("%d succeeded ingestion runs, want %d", got, len(RefreshBulkTypes()))
+	}
+
+	// Second run with an unchanged manifest: nothing is downloaded
+	report, err = RunBulkRefresh(ctx, database, server.Source(), TriggerManual)
+	if err != nil {
+		t.Fatalf("second refresh: %v", err)
+	}
+	for _, bulkType := range RefreshBulkTypes() {
+		if !slices.Contains(report.Skipped, bulkType) {
+			t.Errorf("unchanged %s was not skipped: %s", bulkType, report)
+		}
+		if got := server.Downloads(bulkType); got != 1 {
+			t.Errorf("%s downloaded %d times, want 1", bulkType, got)
+		}
+	}
+	// The catalogs are requested again, but conditionally, and the server answers 304
+	for _, catalog := range []string{"sets", "symbology"} {
+		if !slices.Contains(report.Skipped, catalog) {
+			t.Errorf("unchanged %s was not skipped: %s", catalog, report)
+		}
+	}
+
+	// Third run: the manifest reports a new oracle_cards export, but the file itself is the same,
+	// so the conditional request is answered 304 and the cards are not re-ingested
+	server.SetUpdatedAt("oracle_cards", scryfalltest.DefaultUpdatedAt.Add(24*time.Hour))
+	report, err = RunBulkRefresh(ctx, database, server.Source(), TriggerManual)
+	if err != nil {
+		t.Fatalf("third refresh: %v", err)
+	}
+	if !slices.Contains(report.Skipped, "oracle_cards") {
+		t.Errorf("oracle_cards was not skipped after 304 Not Modified: %s", report)
+	}
+	if got := server.Downloads("oracle_cards"); got != 1 {
+		t.Errorf("oracle_cards downloaded %d times, want 1 (the 304 is not a download)", got)
+	}
+	var status string
+	if err := database.QueryRow(`SELECT last_refresh_status FROM bulk_data WHERE type = 'oracle_cards'`).Scan(&status); err != nil {
+		t.Fatal(err)
+	}
+	if status != refreshStatusNotModified {
+		t.Errorf("oracle_cards last_refresh_status = %q, want %q", status, refreshStatusNotModified)
+	}
+	if got := countRows(t, database, `SELECT count(*) FROM ingestion_runs WHERE bulk_type = 'oracle_cards' AND status = $1`, runStatusNotModified); got != 1 {
+		t.Errorf("%d not_modified oracle_cards runs, want 1", got)
+	}
+}
+
+func TestIngestBulkStreamFixture(t *testing.T) {
+	database := openTestDB(t)
+
+	f, err := os.Open("../fixtures/oracle_cards.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	result, err := IngestBulkStream(database, "oracle_cards", f)
+	if err != nil {
+		t.Fatalf("ingesting oracle_cards: %v", err)
+	}
+	want := fixtureLen(t, "oracle_cards")
+	if result.Cards != want {
+		t.Errorf("ingested %d cards, want %d", result.Cards, want)
+	}
+	if got := countRows(t, database, `SELECT count(*) FROM oracle_cards WHERE retired_at IS NULL`); got != want {
+		t.Errorf("oracle_cards has %d live rows, want %d", got, want)
+	}
+}
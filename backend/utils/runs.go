@@ -0,0 +1,110 @@
+package utils
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+
+	"github.com/lib/pq"
+)
+
+// What started an ingestion run, stored in ingestion_runs.trigger.
+const (
+	TriggerScheduled = "scheduled"
+	TriggerManual    = "manual"
+	TriggerImport    = "import"
+)
+
+// Ingestion run statuses, stored in ingestion_runs.status.
+const (
+	runStatusRunning     = "running"
+	runStatusSucceeded   = "succeeded"
+	runStatusNotModified = "not_modified"
+	runStatusFailed      = "failed"
+)
+
+// startIngestionRun records the start of an ingestion run and returns its ID. bulkDataID may be
+// empty for files that do not come from the bulk data manifest.
+func startIngestionRun(db *sql.DB, bulkType, bulkDataID, source, trigger string) (int64, error) {
+	var runID int64
+	err := db.QueryRow(`
+		INSERT INTO ingestion_runs (bulk_type, bulk_data_id, source, trigger, status)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id;
+	`, bulkType, nullIfEmpty(bulkDataID), source, trigger, runStatusRunning).Scan(&runID)
+	if err != nil {
+		return 0, fmt.Errorf("error recording ingestion run: %w", err)
+	}
+	return runID, nil
+}
+
+// finishIngestionRun records the outcome of an ingestion run: its counts, the cards it rejected and,
+// if runErr is non-nil, the error it failed with. Failing to record it is only logged, so the
+// outcome of the ingestion itself is not masked.
+func finishIngestionRun(db *sql.DB, runID int64, status string, result IngestResult, runErr error) {
+	var errText interface{}
+	if runErr != nil {
+		status = runStatusFailed
+		errText = runErr.Error()
+	}
+	var added, changed, retired int
+	if result.Changelog != nil {
+		added, changed, retired = result.Changelog.Added, result.Changelog.Changed, result.Changelog.Retired
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		log.Printf("⚠️ Error recording end of ingestion run %d: %v\n", runID, err)
+		return
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		UPDATE ingestion_runs
+		SET status = $2, finished_at = now(), bytes = $3, cards_read = $4, cards_added = $5,
+		    cards_changed = $6, cards_retired = $7, cards_rejected = $8, error = $9
+		WHERE id = $1;
+	`, runID, status, result.Bytes, result.Cards, added, changed, retired, len(result.Rejected), errText)
+	if err != nil {
+		log.Printf("⚠️ Error recording end of ingestion run %d: %v\n", runID, err)
+		return
+	}
+
+	if len(result.Rejected) > 0 {
+		ids := make([]string, len(result.Rejected))
+		names := make([]string, len(result.Rejected))
+		reasons := make([]string, len(result.Rejected))
+		for i, rejected := range result.Rejected {
+			ids[i], names[i], reasons[i] = rejected.CardID, rejected.CardName, rejected.Reason
+		}
+		_, err = tx.Exec(`
+			INSERT INTO ingestion_run_errors (run_id, card_id, card_name, reason)
+			SELECT $1, unnest($2::text[]), unnest($3::text[]), unnest($4::text[]);
+		`, runID, pq.Array(ids), pq.Array(names), pq.Array(reasons))
+		if err != nil {
+			log.Printf("⚠️ Error recording rejected cards of ingestion run %d: %v\n", runID, err)
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("⚠️ Error recording end of ingestion run %d: %v\n", runID, err)
+	}
+}
+
+// FailAbandonedRuns marks runs still "running" from a previous process as failed. Call it at
+// startup, before any new run begins.
+func FailAbandonedRuns(db *sql.DB) {
+	res, err := db.Exec(`
+		UPDATE ingestion_runs
+		SET status = $1, finished_at = now(), error = 'interrupted: the backend stopped during the run'
+		WHERE status = $2;
+	`, runStatusFailed, runStatusRunning)
+	if err != nil {
+		log.Printf("⚠️ Error closing abandoned ingestion runs: %v\n", err)
+		return
+	}
+	if n, _ := res.RowsAffected(); n > 0 {
+		log.Printf("⚠️ Marked %d abandoned ingestion run(s) as failed\n", n)
+	}
+}
@@ -1,56 +1,107 @@
 package utils
 
 import (
+	"context"
 	"database/sql"
+	"errors"
+	"fmt"
 	"log"
+	"os"
 	"time"
 
+	"github.com/quehorrifico/mana-tomb/backend/account"
 	"github.com/quehorrifico/mana-tomb/backend/db"
+	"github.com/quehorrifico/mana-tomb/backend/imagecache"
+	"github.com/quehorrifico/mana-tomb/backend/scheduler"
+	"github.com/quehorrifico/mana-tomb/backend/scryfall"
 )
 
-// StartScheduler runs FetchAndStoreBulkData once per day
-func StartScheduler(db *sql.DB) {
-	SetupDatabase()
-	go func() {
-		for {
-			doFetch := false
-			if !doFetch {
-				log.Println("🛑 Fetching and storing process blocked, using existing data")
-			} else {
-				FetchAndParseBulkData(db)
-				FetchAndParseOracleCards(db)
-				ParseAndParseUniqueArtwork(db)
-			}
-			log.Println("⏳ Next bulk data update in 24 hours...")
-			time.Sleep(24 * time.Hour) // Runs once every 24 hours
-		}
-	}()
-}
+// Default job schedules, in UTC. Scryfall publishes new bulk files early each day, and the price
+// snapshot runs after the refresh so it sees the day's prices.
+const (
+	defaultBulkRefreshSchedule    = "0 10 * * *"
+	defaultPriceSnapshotSchedule  = "30 11 * * *"
+	defaultSessionCleanupSchedule = "@hourly"
+	defaultImagePrewarmSchedule   = "0 12 * * *"
+	defaultScheduleJitter         = 5 * time.Minute
+)
 
-// SetupDatabase ensures all domain tables exist.
-func SetupDatabase() {
-	db := db.GetDB()
+// StartScheduler applies pending schema migrations and starts the recurring jobs until ctx is
+// cancelled: the bulk data refresh from src, the daily price snapshot, expired session cleanup and
+// caching the images of deck cards in images. Each schedule is read from SCHEDULE_BULK_REFRESH,
+// SCHEDULE_PRICE_SNAPSHOT, SCHEDULE_SESSION_CLEANUP and SCHEDULE_IMAGE_PREWARM ("off" disables a
+// job), and SCHEDULE_JITTER caps the random delay added to every run. SCRYFALL_REFRESH=off also
+// disables the bulk refresh, keeping existing data.
+func StartScheduler(ctx context.Context, db *sql.DB, src scryfall.CardSource, images *imagecache.Cache) (*scheduler.Scheduler, error) {
+	SetupDatabase()
+	FailAbandonedRuns(db)
 
-	// Setup account tables
-	if err := EnsureUserTable(db); err != nil {
-		log.Fatalf("❌ Failed to create users table: %v", err)
-	}
-	if err := EnsureSessionsTable(db); err != nil {
-		log.Fatalf("❌ Failed to create sessions table: %v", err)
+	jitter := defaultScheduleJitter
+	if v := os.Getenv("SCHEDULE_JITTER"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 0 {
+			return nil, fmt.Errorf("invalid SCHEDULE_JITTER %q", v)
+		}
+		jitter = d
 	}
+	s := scheduler.New(db, jitter)
 
-	// Setup card-related tables
-	if err := EnsureBulkDataTable(db); err != nil {
-		log.Fatalf("❌ Failed to create bulk_data table: %v", err)
+	jobs := []struct {
+		name, env, defaultSpec string
+		run                    func(ctx context.Context) error
+	}{
+		{"bulk_refresh", "SCHEDULE_BULK_REFRESH", defaultBulkRefreshSchedule, bulkRefreshJob(db, src)},
+		{"price_snapshot", "SCHEDULE_PRICE_SNAPSHOT", defaultPriceSnapshotSchedule, func(ctx context.Context) error {
+			return SnapshotPrices(ctx, db)
+		}},
+		{"session_cleanup", "SCHEDULE_SESSION_CLEANUP", defaultSessionCleanupSchedule, func(ctx context.Context) error {
+			n, err := account.DeleteExpiredSessions(ctx, db)
+			if err == nil && n > 0 {
+				log.Printf("🧹 Deleted %d expired session(s)\n", n)
+			}
+			return err
+		}},
+		{"image_prewarm", "SCHEDULE_IMAGE_PREWARM", defaultImagePrewarmSchedule, func(ctx context.Context) error {
+			return images.PrewarmDeckImages(ctx, db)
+		}},
 	}
-	if err := EnsureOracleCardsTable(db); err != nil {
-		log.Fatalf("❌ Failed to create oracle_cards table: %v", err)
+
+	for _, job := range jobs {
+		spec := os.Getenv(job.env)
+		if spec == "" {
+			spec = job.defaultSpec
+		}
+		if spec == "off" || (job.name == "bulk_refresh" && os.Getenv("SCRYFALL_REFRESH") == "off") {
+			log.Printf("🛑 %s disabled\n", job.name)
+			continue
+		}
+		schedule, err := scheduler.ParseSchedule(spec)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", job.env, err)
+		}
+		s.Add(scheduler.Job{Name: job.name, Schedule: schedule, Run: job.run})
 	}
-	if err := EnsureUniqueArtworkTable(db); err != nil {
-		log.Fatalf("❌ Failed to create unique_artwork table: %v", err)
+
+	s.Start(ctx)
+	return s, nil
+}
+
+// bulkRefreshJob returns the scheduled bulk data refresh from src. A refresh already running, such
+// as one triggered by an admin or an offline import, skips the run rather than failing it.
+func bulkRefreshJob(db *sql.DB, src scryfall.CardSource) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		_, err := RunBulkRefresh(ctx, db, src, TriggerScheduled)
+		if errors.Is(err, ErrRefreshRunning) {
+			return fmt.Errorf("%w: %w", scheduler.ErrSkipped, err)
+		}
+		return err
 	}
-	if err := EnsureCommanderDecksTable(db); err != nil {
-		log.Fatalf("❌ Failed to create proto_commander_decks table: %v", err)
+}
+
+// SetupDatabase applies any pending schema migrations.
+func SetupDatabase() {
+	if _, err := db.Migrate(db.GetDB(), false); err != nil {
+		log.Fatalf("❌ Failed to migrate database: %v", err)
 	}
 
 	log.Println("✅ All database tables initialized successfully")
@@ -0,0 +1,38 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/quehorrifico/mana-tomb/backend/scheduler"
+	"github.com/quehorrifico/mana-tomb/backend/scryfall/scryfalltest"
+)
+
+func TestBulkRefreshJobSkipsWhileARefreshRuns(t *testing.T) {
+	database := openTestDB(t)
+	ctx := context.Background()
+	server := scryfalltest.NewServer(os.DirFS("../fixtures"))
+	defer server.Close()
+	job := bulkRefreshJob(database, server.Source())
+
+	release, acquired, err := scheduler.TryLock(ctx, database, refreshLock)
+	if err != nil || !acquired {
+		t.Fatalf("taking the refresh lock: acquired %v, %v", acquired, err)
+	}
+	err = job(ctx)
+	release()
+	if !errors.Is(err, scheduler.ErrSkipped) || !errors.Is(err, ErrRefreshRunning) {
+		t.Errorf("bulk_refresh during a refresh returned %v, want a skipped run", err)
+	}
+	for _, bulkType := range RefreshBulkTypes() {
+		if got := server.Downloads(bulkType); got != 0 {
+			t.Errorf("skipped run downloaded %s %d times", bulkType, got)
+		}
+	}
+
+	if err := job(ctx); err != nil {
+		t.Errorf("bulk_refresh after the refresh: %v", err)
+	}
+}
@@ -0,0 +1,87 @@
+package utils
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/quehorrifico/mana-tomb/backend/models"
+	"github.com/quehorrifico/mana-tomb/backend/scryfall"
+)
+
+// setsRunSource is the source recorded for ingestion runs of the set catalog from a CardSource.
+const setsRunSource = "/sets"
+
+// RefreshSets updates the set catalog from src unless it is unchanged since the last refresh,
+// recording it as an ingestion run. It returns the refresh status recorded for it.
+func RefreshSets(ctx context.Context, db *sql.DB, src scryfall.CardSource, trigger string) (string, error) {
+	return refreshCatalog(ctx, db, "sets", setsRunSource, trigger, src.Sets, InsertSets)
+}
+
+// decodeSetList reads a Scryfall set list (the body of /sets) from r.
+func decodeSetList(r io.Reader) ([]models.Set, error) {
+	var list models.SetListResponse
+	if err := json.NewDecoder(r).Decode(&list); err != nil {
+		return nil, fmt.Errorf("error decoding set list: %w", err)
+	}
+	return list.Data, nil
+}
+
+// InsertSets upserts sets into the sets table in one transaction.
+func InsertSets(db *sql.DB, sets []models.Set) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO sets (id, code, mtgo_code, arena_code, tcgplayer_id, name, set_type, released_at, block_code, block,
+			parent_set_code, card_count, printed_size, digital, foil_only, nonfoil_only, scryfall_uri, uri, icon_svg_uri, search_uri)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20)
+		ON CONFLICT (id) DO UPDATE
+		SET code = EXCLUDED.code,
+		    mtgo_code = EXCLUDED.mtgo_code,
+		    arena_code = EXCLUDED.arena_code,
+		    tcgplayer_id = EXCLUDED.tcgplayer_id,
+		    name = EXCLUDED.name,
+		    set_type = EXCLUDED.set_type,
+		    released_at = EXCLUDED.released_at,
+		    block_code = EXCLUDED.block_code,
+		    block = EXCLUDED.block,
+		    parent_set_code = EXCLUDED.parent_set_code,
+		    card_count = EXCLUDED.card_count,
+		    printed_size = EXCLUDED.printed_size,
+		    digital = EXCLUDED.digital,
+		    foil_only = EXCLUDED.foil_only,
+		    nonfoil_only = EXCLUDED.nonfoil_only,
+		    scryfall_uri = EXCLUDED.scryfall_uri,
+		    uri = EXCLUDED.uri,
+		    icon_svg_uri = EXCLUDED.icon_svg_uri,
+		    search_uri = EXCLUDED.search_uri;
+	`)
+	if err != nil {
+		return fmt.Errorf("error preparing statement: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, set := range sets {
+		var tcgplayerID, printedSize interface{}
+		if set.TCGPlayerID != 0 {
+			tcgplayerID = set.TCGPlayerID
+		}
+		if set.PrintedSize != 0 {
+			printedSize = set.PrintedSize
+		}
+		_, err := stmt.Exec(set.ID, set.Code, nullIfEmpty(set.MTGOCode), nullIfEmpty(set.ArenaCode), tcgplayerID, set.Name, set.SetType,
+			nullIfEmpty(set.ReleasedAt), nullIfEmpty(set.BlockCode), nullIfEmpty(set.Block), nullIfEmpty(set.ParentSetCode), set.CardCount,
+			printedSize, set.Digital, set.FoilOnly, set.NonFoilOnly, set.ScryfallURI, set.URI, set.IconSVGURI, set.SearchURI)
+		if err != nil {
+			return fmt.Errorf("error inserting set %s: %w", set.Code, err)
+		}
+	}
+
+	return tx.Commit()
+}
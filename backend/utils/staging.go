@@ -0,0 +1,328 @@
+package utils
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/quehorrifico/mana-tomb/backend/db"
+	"github.com/quehorrifico/mana-tomb/backend/models"
+)
+
+// Card changelog entry kinds, stored in card_changelog.change.
+const (
+	changeAdded   = "added"
+	changeChanged = "changed"
+	changeRetired = "retired"
+)
+
+// minStagedRatio is the smallest share of the live (non-retired) cards a run must stage before it
+// is merged. A truncated or mostly invalid bulk file would otherwise retire most of the table.
+const minStagedRatio = 0.5
+
+// changelogIgnoredFields are columns whose changes are written but not reported in the changelog.
+// Prices and popularity ranks move on nearly every card every day (price_history tracks prices),
+// and the bookkeeping columns change on every run by design.
+var changelogIgnoredFields = map[string]bool{
+	"prices":       true,
+	"edhrec_rank":  true,
+	"penny_rank":   true,
+	"last_seen_at": true,
+	"retired_at":   true,
+}
+
+// cardTable describes a table of Scryfall card objects keyed by their Scryfall ID. columns lists
+// the columns written per card, in the order of the row values; it must include "id" and "name".
+// If source is set, the table is fed by more than one bulk file: each card is stored with the
+// source that loaded it, and a run only counts and retires the live cards of its own source.
+type cardTable struct {
+	name    string
+	columns []string
+	source  string
+}
+
+func (t cardTable) columnIndex(name string) int {
+	for i, col := range t.columns {
+		if col == name {
+			return i
+		}
+	}
+	panic(fmt.Sprintf("card table %s has no %s column", t.name, name))
+}
+
+// Changelog records what one ingestion run did to a card table.
+type Changelog struct {
+	Table        string
+	RunStartedAt time.Time
+	Added        int
+	Changed      int
+	Retired      int
+}
+
+// NewChangelog starts the changelog of an ingestion run into table.
+func NewChangelog(table string) *Changelog {
+	return &Changelog{Table: table, RunStartedAt: time.Now()}
+}
+
+func (c *Changelog) String() string {
+	return fmt.Sprintf("%s: %d added, %d changed, %d retired", c.Table, c.Added, c.Changed, c.Retired)
+}
+
+// CardLoad stages one ingestion run of a card table. Batches are written with COPY into a
+// temporary "<table>_staging" copy of the live table; Merge then validates the staged cards and
+// moves them into the live table in a single transaction, so readers never see a half-loaded
+// table and a failed run leaves the live table untouched. The staging table lives on a connection
+// of its own, so loads running at the same time, in this process or another instance, each stage
+// into their own table, and Postgres drops it if the run crashes.
+type CardLoad struct {
+	conn      *sql.Conn
+	table     cardTable
+	staging   string
+	Changelog *Changelog
+	Rejected  []models.IngestionRunError
+}
+
+// newCardLoad creates the staging table for t. The caller must Close the load when done.
+func newCardLoad(db *sql.DB, t cardTable) (*CardLoad, error) {
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error acquiring connection: %w", err)
+	}
+	load := &CardLoad{conn: conn, table: t, staging: t.name + "_staging", Changelog: NewChangelog(t.name)}
+
+	_, err = conn.ExecContext(ctx, fmt.Sprintf(`CREATE TEMP TABLE %s (LIKE %s INCLUDING DEFAULTS)`, load.staging, t.name))
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("error creating staging table %s: %w", load.staging, err)
+	}
+	return load, nil
+}
+
+// Close drops the staging table and releases its connection.
+func (l *CardLoad) Close() error {
+	defer l.conn.Close()
+	if _, err := l.conn.ExecContext(context.Background(), fmt.Sprintf(`DROP TABLE IF EXISTS %s`, l.staging)); err != nil {
+		return fmt.Errorf("error dropping staging table %s: %w", l.staging, err)
+	}
+	return nil
+}
+
+// begin starts a transaction on the load's connection, the only one that sees the staging table.
+func (l *CardLoad) begin() (*sql.Tx, error) {
+	return l.conn.BeginTx(context.Background(), nil)
+}
+
+// copyRows validates one batch of card rows and COPYs the valid ones into the staging table.
+// Invalid rows are recorded in Rejected; a COPY failure fails the whole run.
+func (l *CardLoad) copyRows(rows [][]interface{}) error {
+	tx, err := l.begin()
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(pq.CopyIn(l.staging, l.table.columns...))
+	if err != nil {
+		return fmt.Errorf("error preparing COPY into %s: %w", l.staging, err)
+	}
+	defer stmt.Close()
+
+	idIndex, nameIndex := l.table.columnIndex("id"), l.table.columnIndex("name")
+	for _, row := range rows {
+		id, _ := row[idIndex].(string)
+		name, _ := row[nameIndex].(string)
+		if reason := validateCardRow(id, name); reason != "" {
+			log.Printf("⚠️ Skipping card %q (%s) in %s: %s", name, id, l.table.name, reason)
+			l.Rejected = append(l.Rejected, models.IngestionRunError{CardID: id, CardName: name, Reason: reason})
+			continue
+		}
+		if _, err := stmt.Exec(copyValues(row)...); err != nil {
+			return fmt.Errorf("error copying card %s into %s: %w", id, l.staging, err)
+		}
+	}
+
+	// The final Exec without arguments flushes the COPY
+	if _, err := stmt.Exec(); err != nil {
+		return fmt.Errorf("error copying into %s: %w", l.staging, err)
+	}
+	return tx.Commit()
+}
+
+// validateCardRow returns why a card cannot be loaded, or "" if it can.
+func validateCardRow(id, name string) string {
+	if !db.UUIDPattern.MatchString(id) {
+		return "missing or malformed Scryfall ID"
+	}
+	if strings.TrimSpace(name) == "" {
+		return "missing name"
+	}
+	return ""
+}
+
+// copyValues adapts row values for COPY, which sends []byte as bytea: JSON documents must be
+// sent as text to land in JSONB columns.
+func copyValues(row []interface{}) []interface{} {
+	values := make([]interface{}, len(row))
+	for i, v := range row {
+		if b, ok := v.([]byte); ok {
+			v = string(b)
+		}
+		values[i] = v
+	}
+	return values
+}
+
+// Merge moves the staged cards into the live table in one transaction: it records added and
+// changed cards in the changelog, upserts every staged card, retires live cards the run did not
+// contain and finally calls afterMerge, if non-nil, for work that must commit together with the
+// cards. It must only be called once the whole bulk file was staged.
+func (l *CardLoad) Merge(afterMerge func(tx *sql.Tx, staging string) error) error {
+	tx, err := l.begin()
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Bulk files list each card once, but keep the last copy if one ever repeats
+	res, err := tx.Exec(fmt.Sprintf(`DELETE FROM %[1]s a USING %[1]s b WHERE a.id = b.id AND a.ctid < b.ctid`, l.staging))
+	if err != nil {
+		return fmt.Errorf("error removing duplicate cards from %s: %w", l.staging, err)
+	}
+	if n, _ := res.RowsAffected(); n > 0 {
+		log.Printf("⚠️ Dropped %d duplicate card(s) from %s\n", n, l.staging)
+	}
+
+	var staged, live int
+	sourceCond, sourceArgs := l.sourceCondition(1)
+	err = tx.QueryRow(fmt.Sprintf(`
+		SELECT (SELECT count(*) FROM %s), (SELECT count(*) FROM %s WHERE retired_at IS NULL%s)
+	`, l.staging, l.table.name, sourceCond), sourceArgs...).Scan(&staged, &live)
+	if err != nil {
+		return fmt.Errorf("error counting staged cards: %w", err)
+	}
+	if staged == 0 {
+		return fmt.Errorf("no valid cards staged for %s", l.table.name)
+	}
+	if float64(staged) < float64(live)*minStagedRatio {
+		return fmt.Errorf("only %d cards staged for %s, which has %d live cards; refusing to merge", staged, l.table.name, live)
+	}
+
+	if err := l.recordChanges(tx); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(l.mergeSQL(), append([]interface{}{l.Changelog.RunStartedAt}, sourceArgs...)...)
+	if err != nil {
+		return fmt.Errorf("error merging %s into %s: %w", l.staging, l.table.name, err)
+	}
+
+	// Every merged card now has last_seen_at = RunStartedAt, so older ones were not in this run
+	sourceCond, sourceArgs = l.sourceCondition(4)
+	res, err = tx.Exec(fmt.Sprintf(`
+		WITH retired AS (
+			UPDATE %s
+			SET retired_at = now()
+			WHERE retired_at IS NULL AND (last_seen_at IS NULL OR last_seen_at < $1)%s
+			RETURNING id, name
+		)
+		INSERT INTO card_changelog (run_started_at, table_name, card_id, card_name, change)
+		SELECT $1, $2, id, name, $3 FROM retired;
+	`, l.table.name, sourceCond), append([]interface{}{l.Changelog.RunStartedAt, l.table.name, changeRetired}, sourceArgs...)...)
+	if err != nil {
+		return fmt.Errorf("error retiring missing cards: %w", err)
+	}
+	retired, _ := res.RowsAffected()
+	l.Changelog.Retired = int(retired)
+
+	if afterMerge != nil {
+		if err := afterMerge(tx, l.staging); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing merge into %s: %w", l.table.name, err)
+	}
+	return nil
+}
+
+// recordChanges diffs the staged cards against the live table and appends added and changed
+// cards to card_changelog. A retired card that reappears counts as added.
+func (l *CardLoad) recordChanges(tx *sql.Tx) error {
+	res, err := tx.Exec(fmt.Sprintf(`
+		INSERT INTO card_changelog (run_started_at, table_name, card_id, card_name, change)
+		SELECT $1, $2, s.id, s.name, $3
+		FROM %s s
+		LEFT JOIN %s l ON l.id = s.id
+		WHERE l.id IS NULL OR l.retired_at IS NOT NULL;
+	`, l.staging, l.table.name), l.Changelog.RunStartedAt, l.table.name, changeAdded)
+	if err != nil {
+		return fmt.Errorf("error recording added cards: %w", err)
+	}
+	added, _ := res.RowsAffected()
+
+	// Compare the written columns as JSON values, so arrays and JSONB compare by content
+	var compared []string
+	for _, col := range l.table.columns {
+		if !changelogIgnoredFields[col] {
+			compared = append(compared, col)
+		}
+	}
+	res, err = tx.Exec(fmt.Sprintf(`
+		INSERT INTO card_changelog (run_started_at, table_name, card_id, card_name, change, fields)
+		SELECT $1, $2, s.id, s.name, $3, diff.fields
+		FROM %s s
+		JOIN %s l ON l.id = s.id AND l.retired_at IS NULL
+		CROSS JOIN LATERAL (
+			SELECT array_agg(n.key ORDER BY n.key) AS fields
+			FROM jsonb_each(to_jsonb(s)) n
+			JOIN jsonb_each(to_jsonb(l)) o ON o.key = n.key
+			WHERE n.key = ANY($4) AND n.value IS DISTINCT FROM o.value
+		) diff
+		WHERE diff.fields IS NOT NULL;
+	`, l.staging, l.table.name), l.Changelog.RunStartedAt, l.table.name, changeChanged, pq.Array(compared))
+	if err != nil {
+		return fmt.Errorf("error recording changed cards: %w", err)
+	}
+	changed, _ := res.RowsAffected()
+
+	l.Changelog.Added, l.Changelog.Changed = int(added), int(changed)
+	return nil
+}
+
+// sourceCondition restricts a query of the live table to the cards of the load's source, passed
+// as parameter $n. Tables without sources get no condition and no argument.
+func (l *CardLoad) sourceCondition(n int) (string, []interface{}) {
+	if l.table.source == "" {
+		return "", nil
+	}
+	return fmt.Sprintf(" AND source = $%d", n), []interface{}{l.table.source}
+}
+
+// mergeSQL upserts every staged card into the live table, marking it as seen by the current run
+// and clearing any earlier retirement. For tables with sources it also stores the load's source,
+// passed as $2, on each card.
+func (l *CardLoad) mergeSQL() string {
+	updates := make([]string, len(l.table.columns))
+	for i, col := range l.table.columns {
+		updates[i] = fmt.Sprintf("%s = EXCLUDED.%s", col, col)
+	}
+	columns := strings.Join(l.table.columns, ", ")
+	bookkeeping, values := "last_seen_at, retired_at", "$1, NULL"
+	updates = append(updates, "last_seen_at = EXCLUDED.last_seen_at", "retired_at = NULL")
+	if l.table.source != "" {
+		bookkeeping, values = bookkeeping+", source", values+", $2"
+		updates = append(updates, "source = EXCLUDED.source")
+	}
+	return fmt.Sprintf(`
+		INSERT INTO %s (%s, %s)
+		SELECT %s, %s FROM %s
+		ON CONFLICT (id) DO UPDATE
+		SET %s;
+	`, l.table.name, columns, bookkeeping, columns, values, l.staging, strings.Join(updates, ", "))
+}
@@ -0,0 +1,99 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/quehorrifico/mana-tomb/backend/models"
+)
+
+// TestConcurrentCardLoadsKeepTheirOwnStaging stages two loads of the same table side by side and
+// checks neither sees, nor drops, the other's staged cards.
+func TestConcurrentCardLoadsKeepTheirOwnStaging(t *testing.T) {
+	database := openTestDB(t)
+
+	data, err := os.ReadFile("../fixtures/oracle_cards.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var cards []models.OracleCard
+	if err := json.Unmarshal(data, &cards); err != nil {
+		t.Fatal(err)
+	}
+	table := cardTable{name: "oracle_cards", columns: oracleCardColumns}
+
+	first, err := newCardLoad(database, table)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer first.Close()
+	second, err := newCardLoad(database, table)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := InsertOracleCards(first, cards); err != nil {
+		t.Fatal(err)
+	}
+	if err := InsertOracleCards(second, cards[:1]); err != nil {
+		t.Fatal(err)
+	}
+	if err := second.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	var staged int
+	if err := first.conn.QueryRowContext(context.Background(), `SELECT count(*) FROM `+first.staging).Scan(&staged); err != nil {
+		t.Fatalf("first load lost its staging table: %v", err)
+	}
+	if staged != len(cards) {
+		t.Errorf("first load has %d staged cards, want %d", staged, len(cards))
+	}
+	if err := first.Merge(nil); err != nil {
+		t.Fatalf("merging first load: %v", err)
+	}
+}
+
+// TestPrintingsRetireOnlyTheirOwnSource loads printings from all_cards, then from default_cards,
+// and checks the second run neither retires the foreign printings only all_cards lists nor is
+// refused by the staged-count check.
+func TestPrintingsRetireOnlyTheirOwnSource(t *testing.T) {
+	database := openTestDB(t)
+
+	ingest := func(bulkType string) IngestResult {
+		t.Helper()
+		f, err := os.Open("../fixtures/" + bulkType + ".json")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		result, err := IngestBulkStream(database, bulkType, f)
+		if err != nil {
+			t.Fatalf("ingesting %s: %v", bulkType, err)
+		}
+		return result
+	}
+
+	ingest("all_cards")
+	allCards := fixtureLen(t, "all_cards")
+	result := ingest("default_cards")
+	if result.Changelog.Retired != 0 {
+		t.Errorf("default_cards run after all_cards retired %d printings, want 0", result.Changelog.Retired)
+	}
+	if got := countRows(t, database, `SELECT count(*) FROM printings WHERE retired_at IS NULL`); got != allCards {
+		t.Errorf("%d live printings, want all %d from all_cards", got, allCards)
+	}
+	if got := countRows(t, database, `SELECT count(*) FROM printings WHERE source = 'default_cards'`); got != fixtureLen(t, "default_cards") {
+		t.Errorf("%d printings attributed to default_cards, want %d", got, fixtureLen(t, "default_cards"))
+	}
+
+	// Switching back to all_cards takes the English printings over again without retiring any
+	if result := ingest("all_cards"); result.Changelog.Retired != 0 {
+		t.Errorf("all_cards run after default_cards retired %d printings, want 0", result.Changelog.Retired)
+	}
+	if got := countRows(t, database, `SELECT count(*) FROM printings WHERE source = 'all_cards' AND retired_at IS NULL`); got != allCards {
+		t.Errorf("%d live printings attributed to all_cards, want %d", got, allCards)
+	}
+}
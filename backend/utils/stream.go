@@ -0,0 +1,105 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+)
+
+// ingestBatchSize is how many cards are buffered before each database write.
+const ingestBatchSize = 1000
+
+// progressLogInterval is how many cards are processed between progress log lines.
+const progressLogInterval = 10000
+
+// IngestProgress reports how far a streaming ingestion has got.
+type IngestProgress struct {
+	Cards int   // cards decoded and written so far
+	Bytes int64 // bytes read from the source so far
+}
+
+// countingReader counts the bytes read through it so progress can be reported while streaming.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// StreamBulkArray decodes a Scryfall bulk file (one top-level JSON array) element by element
+// and hands batches of at most batchSize items to write, so memory use does not grow with the
+// file size. The batch slice is reused between calls, so write must not keep a reference to it.
+// progress, if non-nil, is called after every batch.
+func StreamBulkArray[T any](r io.Reader, batchSize int, write func([]T) error, progress func(IngestProgress)) (IngestProgress, error) {
+	counter := &countingReader{r: r}
+	dec := json.NewDecoder(counter)
+
+	var p IngestProgress
+
+	tok, err := dec.Token()
+	if err != nil {
+		return p, fmt.Errorf("error reading start of bulk file: %w", err)
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '[' {
+		return p, fmt.Errorf("expected bulk file to start with a JSON array, got %v", tok)
+	}
+
+	batch := make([]T, 0, batchSize)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := write(batch); err != nil {
+			return err
+		}
+		p.Cards += len(batch)
+		p.Bytes = counter.n
+		batch = batch[:0]
+		if progress != nil {
+			progress(p)
+		}
+		return nil
+	}
+
+	for dec.More() {
+		var item T
+		if err := dec.Decode(&item); err != nil {
+			return p, fmt.Errorf("error decoding item %d: %w", p.Cards+len(batch), err)
+		}
+		batch = append(batch, item)
+		if len(batch) >= batchSize {
+			if err := flush(); err != nil {
+				return p, err
+			}
+		}
+	}
+
+	if _, err := dec.Token(); err != nil {
+		return p, fmt.Errorf("error reading end of bulk file: %w", err)
+	}
+	if err := flush(); err != nil {
+		return p, err
+	}
+
+	p.Bytes = counter.n
+	return p, nil
+}
+
+// logIngestProgress returns a progress callback that logs roughly every progressLogInterval cards.
+func logIngestProgress(tableName string) func(IngestProgress) {
+	next := progressLogInterval
+	return func(p IngestProgress) {
+		if p.Cards < next {
+			return
+		}
+		log.Printf("⏳ %s: %d cards processed, %.1f MB read\n", tableName, p.Cards, float64(p.Bytes)/(1<<20))
+		for next <= p.Cards {
+			next += progressLogInterval
+		}
+	}
+}
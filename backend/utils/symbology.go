@@ -0,0 +1,78 @@
+package utils
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/lib/pq"
+	"github.com/quehorrifico/mana-tomb/backend/models"
+	"github.com/quehorrifico/mana-tomb/backend/scryfall"
+)
+
+// symbologyRunSource is the source recorded for ingestion runs of the symbology from a CardSource.
+const symbologyRunSource = "/symbology"
+
+// RefreshSymbology updates the card_symbols table from src unless it is unchanged since the last refresh,
+// recording it as an ingestion run. It returns the refresh status recorded for it.
+func RefreshSymbology(ctx context.Context, db *sql.DB, src scryfall.CardSource, trigger string) (string, error) {
+	return refreshCatalog(ctx, db, "symbology", symbologyRunSource, trigger, src.Symbology, InsertCardSymbols)
+}
+
+// decodeSymbology reads a Scryfall symbol list (the body of /symbology) from r.
+func decodeSymbology(r io.Reader) ([]models.CardSymbol, error) {
+	var list models.SymbologyResponse
+	if err := json.NewDecoder(r).Decode(&list); err != nil {
+		return nil, fmt.Errorf("error decoding symbology: %w", err)
+	}
+	return list.Data, nil
+}
+
+// InsertCardSymbols upserts symbols into the card_symbols table in one transaction.
+func InsertCardSymbols(db *sql.DB, symbols []models.CardSymbol) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO card_symbols (symbol, svg_uri, loose_variant, english, transposable, represents_mana, appears_in_mana_costs,
+			mana_value, hybrid, phyrexian, funny, colors, gatherer_alternates)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		ON CONFLICT (symbol) DO UPDATE
+		SET svg_uri = EXCLUDED.svg_uri,
+		    loose_variant = EXCLUDED.loose_variant,
+		    english = EXCLUDED.english,
+		    transposable = EXCLUDED.transposable,
+		    represents_mana = EXCLUDED.represents_mana,
+		    appears_in_mana_costs = EXCLUDED.appears_in_mana_costs,
+		    mana_value = EXCLUDED.mana_value,
+		    hybrid = EXCLUDED.hybrid,
+		    phyrexian = EXCLUDED.phyrexian,
+		    funny = EXCLUDED.funny,
+		    colors = EXCLUDED.colors,
+		    gatherer_alternates = EXCLUDED.gatherer_alternates;
+	`)
+	if err != nil {
+		return fmt.Errorf("error preparing statement: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, symbol := range symbols {
+		colors := symbol.Colors
+		if colors == nil {
+			colors = []string{}
+		}
+		_, err := stmt.Exec(symbol.Symbol, nullIfEmpty(symbol.SVGURI), nullIfEmpty(symbol.LooseVariant), symbol.English,
+			symbol.Transposable, symbol.RepresentsMana, symbol.AppearsInManaCosts, symbol.ManaValue, symbol.Hybrid,
+			symbol.Phyrexian, symbol.Funny, pq.Array(colors), pq.Array(symbol.GathererAlternates))
+		if err != nil {
+			return fmt.Errorf("error inserting symbol %s: %w", symbol.Symbol, err)
+		}
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,46 @@
+package utils
+
+import (
+	"database/sql"
+	"os"
+	"testing"
+
+	"github.com/quehorrifico/mana-tomb/backend/db"
+)
+
+// emptyTablesSQL empties every table but the migrations bookkeeping, whatever tables the
+// migrations created.
+const emptyTablesSQL = `
+	DO $$
+	DECLARE tables TEXT;
+	BEGIN
+		SELECT string_agg(quote_ident(tablename), ', ') INTO tables
+		FROM pg_tables WHERE schemaname = current_schema() AND tablename <> 'schema_migrations';
+		IF tables IS NOT NULL THEN
+			EXECUTE 'TRUNCATE ' || tables || ' CASCADE';
+		END IF;
+	END $$;
+`
+
+// openTestDB connects to the throwaway database named by TEST_DATABASE_URL, migrates it and empties
+// its tables. Tests that need a database are skipped when it is not set.
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL not set; skipping database test")
+	}
+	database, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatalf("opening test database: %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+
+	if _, err := db.Migrate(database, false); err != nil {
+		t.Fatalf("migrating test database: %v", err)
+	}
+	if _, err := database.Exec(emptyTablesSQL); err != nil {
+		t.Fatalf("emptying test database: %v", err)
+	}
+	return database
+}